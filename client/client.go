@@ -0,0 +1,231 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/server"
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// maximum size of a single streamed result line
+const maxResultSize = 10 * 1024 * 1024
+
+// Client executes uncover searches on a remote uncover api server
+// (uncover serve) with the same semantics as the local uncover.Service
+type Client struct {
+	Options    *uncover.Options
+	URL        string
+	AuthToken  string
+	HTTPClient *http.Client
+	// Raw requests the raw engine response of results
+	Raw bool
+}
+
+var _ uncover.Executor = &Client{}
+
+// New creates a new client for the uncover server at given url
+func New(url, authToken string, opts *uncover.Options) (*Client, error) {
+	if url == "" {
+		return nil, errorutil.NewWithTag("client", "server url cannot be empty")
+	}
+	if opts == nil {
+		return nil, errorutil.NewWithTag("client", "options cannot be nil")
+	}
+	return &Client{
+		Options:    opts,
+		URL:        strings.TrimSuffix(url, "/"),
+		AuthToken:  authToken,
+		HTTPClient: &http.Client{},
+	}, nil
+}
+
+// Execute submits a job to the server and streams its results
+func (c *Client) Execute(ctx context.Context) (<-chan sources.Result, error) {
+	if len(c.Options.Agents) == 0 {
+		return nil, errorutil.NewWithTag("client", "no agent/source specified")
+	}
+	info, err := c.Submit(ctx, server.JobRequest{
+		Queries: c.Options.Queries,
+		Engines: c.Options.Agents,
+		Limit:   c.Options.Limit,
+		Raw:     c.Raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/api/v1/jobs/"+info.ID+"/results", nil)
+	if err != nil {
+		c.cancel(info.ID)
+		return nil, err
+	}
+
+	results := make(chan sources.Result, uncover.DefaultChannelBuffSize)
+	go func() {
+		defer close(results)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		send := func(result sources.Result) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case results <- result:
+				return true
+			}
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxResultSize)
+		for scanner.Scan() {
+			var jobResult server.JobResult
+			if err := json.Unmarshal(scanner.Bytes(), &jobResult); err != nil {
+				send(sources.Result{Source: "uncover", Error: err})
+				continue
+			}
+			if !send(toResult(jobResult)) {
+				break
+			}
+		}
+		if ctx.Err() != nil {
+			c.cancel(info.ID)
+			return
+		}
+		if err := scanner.Err(); err != nil {
+			send(sources.Result{Source: "uncover", Error: err})
+			return
+		}
+		// report jobs that failed on the server side
+		if info, err := c.Job(ctx, info.ID); err == nil && info.Status == server.JobFailed {
+			send(sources.Result{Source: "uncover", Error: errors.New(info.Error)})
+		}
+	}()
+	return results, nil
+}
+
+// ExecuteWithCallback calls callback for each result of the remote job
+func (c *Client) ExecuteWithCallback(ctx context.Context, callback func(result sources.Result)) error {
+	if callback == nil {
+		return errorutil.NewWithTag("client", "result callback cannot be nil")
+	}
+	ch, err := c.Execute(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case result, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			callback(result)
+		}
+	}
+}
+
+// Submit submits a search job to the server
+func (c *Client) Submit(ctx context.Context, request server.JobRequest) (*server.JobInfo, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	info := &server.JobInfo{}
+	return info, c.doJSON(ctx, http.MethodPost, "/api/v1/jobs", body, info)
+}
+
+// Job returns the status of the job with given id
+func (c *Client) Job(ctx context.Context, id string) (*server.JobInfo, error) {
+	info := &server.JobInfo{}
+	return info, c.doJSON(ctx, http.MethodGet, "/api/v1/jobs/"+id, nil, info)
+}
+
+// Cancel cancels the job with given id
+func (c *Client) Cancel(ctx context.Context, id string) (*server.JobInfo, error) {
+	info := &server.JobInfo{}
+	return info, c.doJSON(ctx, http.MethodDelete, "/api/v1/jobs/"+id, nil, info)
+}
+
+// AllAgents returns all agents supported by the server
+func (c *Client) AllAgents(ctx context.Context) ([]string, error) {
+	var agents []string
+	return agents, c.doJSON(ctx, http.MethodGet, "/api/v1/engines", nil, &agents)
+}
+
+// cancel cancels a job on a best effort basis once the caller went away
+func (c *Client) cancel(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = c.Cancel(ctx, id)
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, body []byte, data interface{}) error {
+	resp, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return json.NewDecoder(resp.Body).Decode(data)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, c.URL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.AuthToken != "" {
+		request.Header.Set("Authorization", "Bearer "+c.AuthToken)
+	}
+	resp, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		var apiError struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiError)
+		return nil, errorutil.NewWithTag("client", "%s %s failed with status %d: %s", method, path, resp.StatusCode, apiError.Error)
+	}
+	return resp, nil
+}
+
+// toResult converts a streamed job result to an agent result
+func toResult(jobResult server.JobResult) sources.Result {
+	result := jobResult.Result
+	if jobResult.Error != "" {
+		result.Error = errors.New(jobResult.Error)
+	}
+	if len(jobResult.Raw) > 0 {
+		var raw string
+		if err := json.Unmarshal(jobResult.Raw, &raw); err == nil {
+			result.Raw = []byte(raw)
+		} else {
+			result.Raw = jobResult.Raw
+		}
+	}
+	return result
+}
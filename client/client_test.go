@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/server"
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+type testAgent struct{}
+
+func (agent *testAgent) Name() string {
+	return "shodan"
+}
+
+func (agent *testAgent) Query(_ *sources.Session, query *sources.Query) (chan sources.Result, error) {
+	results := make(chan sources.Result)
+	go func() {
+		defer close(results)
+		for i := 0; i < query.Limit; i++ {
+			results <- sources.Result{Source: agent.Name(), IP: "127.0.0.1", Port: i + 1, Raw: []byte(`{"query":"` + query.Query + `"}`)}
+		}
+	}()
+	return results, nil
+}
+
+func TestClientExecuteWithCallback(t *testing.T) {
+	session, err := sources.NewSession(&sources.Keys{}, 0, 5, 0, []string{"shodan"}, time.Second, "")
+	require.Nil(t, err)
+	service := &uncover.Service{
+		Options:  &uncover.Options{},
+		Agents:   []sources.Agent{&testAgent{}},
+		Session:  session,
+		Provider: &sources.Provider{Shodan: []string{"test"}},
+	}
+	apiServer, err := server.New(service, &server.Options{AuthToken: "secret"})
+	require.Nil(t, err)
+	ts := httptest.NewServer(apiServer)
+	defer ts.Close()
+
+	client, err := New(ts.URL, "secret", &uncover.Options{Agents: []string{"shodan"}, Queries: []string{"a", "b"}, Limit: 2})
+	require.Nil(t, err)
+	client.Raw = true
+
+	var results []sources.Result
+	err = client.ExecuteWithCallback(context.Background(), func(result sources.Result) {
+		results = append(results, result)
+	})
+	require.Nil(t, err)
+	require.Len(t, results, 4)
+	for _, result := range results {
+		require.Nil(t, result.Error)
+		require.Equal(t, "shodan", result.Source)
+		require.Equal(t, "127.0.0.1", result.IP)
+		require.Contains(t, result.RawData(), `"query"`)
+	}
+
+	client.AuthToken = "wrong"
+	_, err = client.Execute(context.Background())
+	require.ErrorContains(t, err, "401")
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"os"
+	"os/signal"
+	"syscall"
 
 	// Attempts to increase the OS file descriptors - Fail silently
 	_ "github.com/projectdiscovery/fdmax/autofdmax"
@@ -10,6 +13,19 @@ import (
 )
 
 func main() {
+	defer runner.HandlePanic()
+
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		if err := runner.Keys(runner.ParseKeysOptions(os.Args[2:])); err != nil {
+			gologger.Fatal().Msgf("%s\n", err)
+		}
+		return
+	}
+
 	// Parse the command line flags and read config files
 	options := runner.ParseOptions()
 
@@ -27,3 +43,15 @@ func main() {
 		newRunner.Close()
 	}
 }
+
+// serve runs uncover in api server mode until interrupted
+func serve() {
+	options := runner.ParseServeOptions(os.Args[2:])
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := runner.Serve(ctx, options); err != nil {
+		gologger.Fatal().Msgf("Could not run api server: %s\n", err)
+	}
+}
@@ -0,0 +1,56 @@
+package uncover
+
+import (
+	"context"
+
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// EngineCount contains the total number of results of a query of an engine
+type EngineCount struct {
+	Engine string `json:"engine"`
+	Query  string `json:"query"`
+	Total  int    `json:"total"`
+	// Error is set if the engine failed or cannot count results
+	Error string `json:"error,omitempty"`
+}
+
+// Count returns the total number of results of all queries per engine without
+// retrieving them, engines without count support are reported with an error
+func (s *Service) Count(ctx context.Context) ([]EngineCount, error) {
+	if err := s.nilCheck(); err != nil {
+		return nil, err
+	}
+	if len(s.Agents) == 0 {
+		return nil, errorutil.NewWithTag("uncover", "no agent/source specified")
+	}
+
+	var counts []EngineCount
+	for _, q := range s.Options.Queries {
+		for _, agent := range s.Agents {
+			if ctx.Err() != nil {
+				return counts, ctx.Err()
+			}
+			count := EngineCount{Engine: agent.Name(), Query: q}
+			countAgent, ok := agent.(sources.CountAgent)
+			if !ok {
+				count.Error = "counting results is not supported"
+				counts = append(counts, count)
+				continue
+			}
+			total, err := countAgent.Count(s.Session.WithContext(ctx), &sources.Query{Query: q, Limit: s.Options.Limit, Options: s.Options.engineOptions(agent.Name())})
+			if err != nil {
+				err = s.Session.Redactor.Error(err)
+				s.Stats.AddError(agent.Name(), err)
+				s.Metrics.AddError(agent.Name(), err)
+				count.Error = err.Error()
+			} else {
+				count.Total = total
+				s.Stats.SetTotal(agent.Name(), q, total)
+			}
+			counts = append(counts, count)
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,58 @@
+package uncover
+
+import (
+	"context"
+
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// EngineFacets contains the facets of a query returned by an engine
+type EngineFacets struct {
+	Engine string `json:"engine"`
+	Query  string `json:"query"`
+	sources.Facets
+	// Error is set if the engine failed or does not support facets
+	Error string `json:"error,omitempty"`
+}
+
+// Facets returns the most common values of the facet fields (e.g. port, country:20)
+// of all queries per engine, engines without aggregation api are reported with an error
+func (s *Service) Facets(ctx context.Context, fields []string) ([]EngineFacets, error) {
+	if err := s.nilCheck(); err != nil {
+		return nil, err
+	}
+	switch {
+	case len(s.Agents) == 0:
+		return nil, errorutil.NewWithTag("uncover", "no agent/source specified")
+	case len(fields) == 0:
+		return nil, errorutil.NewWithTag("uncover", "no facet field specified")
+	}
+
+	var engineFacets []EngineFacets
+	for _, q := range s.Options.Queries {
+		for _, agent := range s.Agents {
+			if ctx.Err() != nil {
+				return engineFacets, ctx.Err()
+			}
+			result := EngineFacets{Engine: agent.Name(), Query: q}
+			facetAgent, ok := agent.(sources.FacetAgent)
+			if !ok {
+				result.Error = "facets are not supported"
+				engineFacets = append(engineFacets, result)
+				continue
+			}
+			facets, err := facetAgent.Facets(s.Session.WithContext(ctx), &sources.Query{Query: q, Limit: s.Options.Limit, Options: s.Options.engineOptions(agent.Name())}, fields)
+			if err != nil {
+				err = s.Session.Redactor.Error(err)
+				s.Stats.AddError(agent.Name(), err)
+				s.Metrics.AddError(agent.Name(), err)
+				result.Error = err.Error()
+			} else {
+				result.Facets = *facets
+			}
+			engineFacets = append(engineFacets, result)
+		}
+	}
+	return engineFacets, nil
+}
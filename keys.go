@@ -0,0 +1,72 @@
+package uncover
+
+import (
+	"errors"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Key validation statuses
+const (
+	KeyValid       = "valid"
+	KeyInvalid     = "invalid"
+	KeyUnsupported = "unsupported"
+	KeyError       = "error"
+)
+
+// KeyStatus is the validation status of a provider key
+type KeyStatus struct {
+	Engine string `json:"engine"`
+	// Key is the masked key
+	Key    string `json:"key"`
+	Origin string `json:"origin,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ValidateKeys validates all keys of the provider with the cheapest authenticated
+// request of each engine, only MaxRetry, Timeout and Proxy of opts are used
+func ValidateKeys(provider *sources.Provider, opts *Options) ([]KeyStatus, error) {
+	var engines []string
+	for _, name := range provider.Names() {
+		if len(provider.EngineKeys(name)) > 0 {
+			engines = append(engines, name)
+		}
+	}
+	session, err := sources.NewSession(&sources.Keys{}, opts.MaxRetry, opts.Timeout, 0, engines, time.Second, opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	session.Redactor.Add(provider.Secrets()...)
+
+	var statuses []KeyStatus
+	for _, engine := range engines {
+		validator, ok := newAgent(engine).(sources.KeyValidator)
+		for _, key := range provider.EngineKeys(engine) {
+			status := KeyStatus{Engine: engine, Key: sources.MaskKey(key), Origin: provider.Origin(key)}
+			if !ok {
+				status.Status = KeyUnsupported
+				status.Error = "key validation is not supported for this engine"
+				statuses = append(statuses, status)
+				continue
+			}
+			single := &sources.Provider{}
+			_ = single.AddKey(engine, key)
+			keys := single.GetKeys()
+			err := validator.ValidateKey(session.WithKeys(&keys))
+			switch {
+			case err == nil:
+				status.Status = KeyValid
+			case errors.Is(err, sources.ErrInvalidKey):
+				status.Status = KeyInvalid
+				status.Error = session.Redactor.Error(err).Error()
+			default:
+				status.Status = KeyError
+				status.Error = session.Redactor.Error(err).Error()
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
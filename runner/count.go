@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/uncover"
+)
+
+// runCount writes the total number of results of all queries per engine instead of their results
+func (r *Runner) runCount(ctx context.Context) error {
+	counts, err := r.service.Count(ctx)
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		if count.Error != "" {
+			gologger.Warning().Label(count.Engine).Msgf("%s\n", count.Error)
+		}
+	}
+	if r.options.JSON {
+		for _, count := range counts {
+			data, err := json.Marshal(count)
+			if err != nil {
+				return err
+			}
+			r.outputWriter.Write(data)
+		}
+		return nil
+	}
+	var matrix bytes.Buffer
+	printCounts(&matrix, counts)
+	r.outputWriter.Write(bytes.TrimSuffix(matrix.Bytes(), []byte("\n")))
+	return nil
+}
+
+// printCounts prints the totals as a matrix of queries and engines,
+// engines which failed to count results are marked with -
+func printCounts(w io.Writer, counts []uncover.EngineCount) {
+	var engines, queries []string
+	totals := make(map[string]map[string]string)
+	for _, count := range counts {
+		if _, ok := totals[count.Query]; !ok {
+			queries = append(queries, count.Query)
+			totals[count.Query] = make(map[string]string)
+		}
+		if !slices.Contains(engines, count.Engine) {
+			engines = append(engines, count.Engine)
+		}
+		total := "-"
+		if count.Error == "" {
+			total = fmt.Sprint(count.Total)
+		}
+		totals[count.Query][count.Engine] = total
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "QUERY\t%s\n", strings.ToUpper(strings.Join(engines, "\t")))
+	for _, query := range queries {
+		row := []string{query}
+		for _, engine := range engines {
+			row = append(row, totals[query][engine])
+		}
+		_, _ = fmt.Fprintf(tw, "%s\n", strings.Join(row, "\t"))
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,23 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/projectdiscovery/uncover"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintCounts(t *testing.T) {
+	var buf bytes.Buffer
+	printCounts(&buf, []uncover.EngineCount{
+		{Engine: "shodan", Query: "nginx", Total: 1500},
+		{Engine: "zoomeye", Query: "nginx", Error: "empty zoomeye keys"},
+		{Engine: "shodan", Query: "jira", Total: 42},
+		{Engine: "zoomeye", Query: "jira", Total: 7},
+	})
+	require.Equal(t, `QUERY  SHODAN  ZOOMEYE
+nginx  1500    -
+jira   42      7
+`, buf.String())
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// executeCVEs searches the assets vulnerable to the cves with the cve query of each engine,
+// results are marked as vulnerable to the searched cve
+func (r *Runner) executeCVEs(ctx context.Context, callback func(result sources.Result)) error {
+	for _, engine := range r.options.Engine {
+		for _, cve := range r.options.CVE {
+			query := sources.CVEQuery(engine, cve)
+			if query == "" {
+				return errorutil.NewWithTag(engine, "cve search is not supported")
+			}
+			fork := r.service.Fork(&uncover.Options{Agents: []string{engine}, Queries: []string{query}, Limit: r.options.Limit})
+			err := fork.ExecuteWithCallback(ctx, func(result sources.Result) {
+				if result.Error == nil {
+					result.Vulns = sources.NormalizeVulns(append(result.Vulns, cve))
+				}
+				callback(result)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cveGroup contains the assets vulnerable to a cve
+type cveGroup struct {
+	CVE    string   `json:"cve"`
+	Assets []string `json:"assets"`
+}
+
+// cveGroups groups the assets of results by the cves they are vulnerable to
+type cveGroups struct {
+	sync.Mutex
+	assets map[string][]string
+}
+
+func newCVEGroups() *cveGroups {
+	return &cveGroups{assets: make(map[string][]string)}
+}
+
+// add adds the asset of the result to the groups of its cves
+func (g *cveGroups) add(result sources.Result) {
+	asset := result.IP
+	if asset == "" {
+		asset = result.Host
+	}
+	if result.Port > 0 {
+		asset = net.JoinHostPort(asset, fmt.Sprint(result.Port))
+	}
+	if asset == "" {
+		return
+	}
+	g.Lock()
+	defer g.Unlock()
+	for _, cve := range result.Vulns {
+		if !slices.Contains(g.assets[cve], asset) {
+			g.assets[cve] = append(g.assets[cve], asset)
+		}
+	}
+}
+
+// groups returns the groups sorted by cve
+func (g *cveGroups) groups() []cveGroup {
+	g.Lock()
+	defer g.Unlock()
+	groups := make([]cveGroup, 0, len(g.assets))
+	for cve, assets := range g.assets {
+		groups = append(groups, cveGroup{CVE: cve, Assets: assets})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].CVE < groups[j].CVE
+	})
+	return groups
+}
+
+// write writes the groups as JSON lines or as a table
+func (g *cveGroups) write(outputWriter *OutputWriter, jsonOutput bool) {
+	groups := g.groups()
+	if jsonOutput {
+		for _, group := range groups {
+			data, _ := json.Marshal(group)
+			outputWriter.Write(data)
+		}
+		return
+	}
+	if len(groups) == 0 {
+		return
+	}
+	var table bytes.Buffer
+	printCVEGroups(&table, groups)
+	outputWriter.Write(bytes.TrimSuffix(table.Bytes(), []byte("\n")))
+}
+
+// printCVEGroups prints the assets of each cve as a table
+func printCVEGroups(w io.Writer, groups []cveGroup) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "CVE\tASSETS\tASSET\n")
+	for _, group := range groups {
+		for _, asset := range group.Assets {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\n", group.CVE, len(group.Assets), asset)
+		}
+	}
+	_ = tw.Flush()
+}
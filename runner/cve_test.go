@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCVEGroups(t *testing.T) {
+	groups := newCVEGroups()
+	groups.add(sources.Result{IP: "10.0.0.1", Port: 443, Vulns: []string{"CVE-2021-44228", "CVE-2019-0708"}})
+	groups.add(sources.Result{IP: "10.0.0.1", Port: 443, Host: "example.com", Vulns: []string{"CVE-2021-44228"}})
+	groups.add(sources.Result{Host: "example.com", Vulns: []string{"CVE-2021-44228"}})
+	groups.add(sources.Result{IP: "10.0.0.2", Port: 80})
+
+	require.Equal(t, []cveGroup{
+		{CVE: "CVE-2019-0708", Assets: []string{"10.0.0.1:443"}},
+		{CVE: "CVE-2021-44228", Assets: []string{"10.0.0.1:443", "example.com"}},
+	}, groups.groups())
+
+	var buf bytes.Buffer
+	printCVEGroups(&buf, groups.groups())
+	require.Equal(t, `CVE             ASSETS  ASSET
+CVE-2019-0708   1       10.0.0.1:443
+CVE-2021-44228  2       10.0.0.1:443
+CVE-2021-44228  2       example.com
+`, buf.String())
+}
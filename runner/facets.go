@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/uncover"
+)
+
+// runFacets writes the facets of all queries per engine instead of their results
+func (r *Runner) runFacets(ctx context.Context) error {
+	engineFacets, err := r.service.Facets(ctx, r.options.Facets)
+	if err != nil {
+		return err
+	}
+	for _, facets := range engineFacets {
+		if facets.Error != "" {
+			gologger.Warning().Label(facets.Engine).Msgf("%s\n", facets.Error)
+		}
+	}
+	if r.options.JSON {
+		for _, facets := range engineFacets {
+			data, err := json.Marshal(facets)
+			if err != nil {
+				return err
+			}
+			r.outputWriter.Write(data)
+		}
+		return nil
+	}
+	var table bytes.Buffer
+	printFacets(&table, engineFacets)
+	r.outputWriter.Write(bytes.TrimSuffix(table.Bytes(), []byte("\n")))
+	return nil
+}
+
+// printFacets prints the facets of engines without errors as a table
+func printFacets(w io.Writer, engineFacets []uncover.EngineFacets) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "ENGINE\tQUERY\tTOTAL\tFIELD\tVALUE\tCOUNT\n")
+	for _, facets := range engineFacets {
+		if facets.Error != "" {
+			continue
+		}
+		fields := make([]string, 0, len(facets.Fields))
+		for field := range facets.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			for _, value := range facets.Fields[field] {
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%d\n", facets.Engine, facets.Query, facets.Total, field, value.Value, value.Count)
+			}
+		}
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintFacets(t *testing.T) {
+	var buf bytes.Buffer
+	printFacets(&buf, []uncover.EngineFacets{
+		{
+			Engine: "shodan",
+			Query:  "nginx",
+			Facets: sources.Facets{Total: 15, Fields: map[string][]sources.FacetValue{
+				"port":    {{Value: "443", Count: 10}, {Value: "80", Count: 5}},
+				"country": {{Value: "US", Count: 15}},
+			}},
+		},
+		{Engine: "google", Query: "nginx", Error: "facets are not supported"},
+	})
+	require.Equal(t, `ENGINE  QUERY  TOTAL  FIELD    VALUE  COUNT
+shodan  nginx  15     country  US     15
+shodan  nginx  15     port     443    10
+shodan  nginx  15     port     80     5
+`, buf.String())
+}
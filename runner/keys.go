@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/projectdiscovery/goflags"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/gologger/formatter"
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// Subcommands of the keys subcommand
+const (
+	KeysList     = "list"
+	KeysAdd      = "add"
+	KeysRemove   = "remove"
+	KeysValidate = "validate"
+)
+
+// KeysOptions contains the configuration options of the keys subcommand
+type KeysOptions struct {
+	Command string
+	Engine  string
+	Key     string
+	// KeystoreWrite is the location to write the configured keys to as encrypted keystore
+	KeystoreWrite string
+	ProviderFile  string
+	Timeout       int
+	Retries       int
+	Proxy         string
+	NoColor       bool
+}
+
+// ParseKeysOptions parses the command line arguments of the keys subcommand
+func ParseKeysOptions(args []string) *KeysOptions {
+	options := &KeysOptions{}
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		options.Command, args = args[0], args[1:]
+	}
+	flagSet := goflags.NewFlagSet()
+	flagSet.SetDescription(`manage provider keys: uncover keys list|add|remove|validate [flags] or uncover keys -keystore-write <file>`)
+
+	flagSet.CreateGroup("keys", "Keys",
+		flagSet.StringVarP(&options.Engine, "engine", "e", "", "engine of the key to add or remove"),
+		flagSet.StringVarP(&options.Key, "key", "k", "", "key to add or remove (email:key, token:org-id and key:cx for fofa, censys and google)"),
+		flagSet.StringVarP(&options.KeystoreWrite, "keystore-write", "kw", "", "write all configured keys to an encrypted keystore file (passphrase from $"+sources.KeystorePassphraseEnv+")"),
+	)
+
+	flagSet.CreateGroup("config", "Config",
+		flagSet.StringVarP(&options.ProviderFile, "provider", "pc", sources.DefaultProviderConfigLocation, "provider configuration file"),
+		flagSet.IntVar(&options.Timeout, "timeout", 30, "timeout in seconds"),
+		flagSet.IntVar(&options.Retries, "retry", 0, "number of times to retry a failed validation request"),
+		flagSet.StringVar(&options.Proxy, "proxy", "", "http proxy to use with uncover"),
+		flagSet.BoolVarP(&options.NoColor, "no-color", "nc", false, "disable colors in output"),
+	)
+
+	if err := flagSet.Parse(args...); err != nil {
+		gologger.Fatal().Msg(err.Error())
+	}
+	if options.NoColor {
+		gologger.DefaultLogger.SetFormatter(formatter.NewCLI(true))
+	}
+	if options.ProviderFile == sources.DefaultProviderConfigLocation {
+		createDefaultProviderConfig()
+	}
+	return options
+}
+
+// Keys runs the keys subcommand
+func Keys(options *KeysOptions) error {
+	if options.KeystoreWrite != "" {
+		return writeKeystore(options)
+	}
+	switch options.Command {
+	case KeysList:
+		provider := loadProvider(options.ProviderFile)
+		redactLogs(sources.NewRedactor(provider.Secrets()...))
+		listKeys(os.Stdout, provider)
+		return nil
+	case KeysAdd, KeysRemove:
+		return editKeys(options)
+	case KeysValidate:
+		provider := loadProvider(options.ProviderFile)
+		redactLogs(sources.NewRedactor(provider.Secrets()...))
+		statuses, err := uncover.ValidateKeys(provider, &uncover.Options{
+			MaxRetry: options.Retries,
+			Timeout:  options.Timeout,
+			Proxy:    options.Proxy,
+		})
+		if err != nil {
+			return err
+		}
+		printKeyStatuses(os.Stdout, statuses)
+		var invalid int
+		var unsupported []string
+		for _, status := range statuses {
+			switch status.Status {
+			case uncover.KeyInvalid:
+				invalid++
+			case uncover.KeyUnsupported:
+				if !slices.Contains(unsupported, status.Engine) {
+					unsupported = append(unsupported, status.Engine)
+				}
+			}
+		}
+		if len(unsupported) > 0 {
+			gologger.Warning().Msgf("Keys of %s could not be validated", strings.Join(unsupported, ", "))
+		}
+		if invalid > 0 {
+			return errorutil.NewWithTag("keys", "%d invalid or expired keys found", invalid)
+		}
+		return nil
+	}
+	return errorutil.NewWithTag("keys", "unknown keys command %q, supported commands are list, add, remove and validate", options.Command)
+}
+
+// loadProvider loads the keys of the provider config file and env variables
+func loadProvider(location string) *sources.Provider {
+	if location == sources.DefaultProviderConfigLocation {
+		return sources.NewProvider()
+	}
+	return sources.NewProvider(sources.WithProviderConfig(location))
+}
+
+// editKeys adds or removes a key of the provider config file
+func editKeys(options *KeysOptions) error {
+	if options.Engine == "" || options.Key == "" {
+		return errorutil.NewWithTag("keys", "engine (-e) and key (-k) are required")
+	}
+	provider, err := sources.ReadProviderConfig(options.ProviderFile)
+	if err != nil {
+		return err
+	}
+	if options.Command == KeysAdd {
+		err = provider.AddKey(options.Engine, options.Key)
+	} else {
+		err = provider.RemoveKey(options.Engine, options.Key)
+	}
+	if err != nil {
+		return err
+	}
+	if err := provider.WriteProviderConfig(options.ProviderFile); err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not write provider config %s", options.ProviderFile)
+	}
+	action := "Added"
+	if options.Command == KeysRemove {
+		action = "Removed"
+	}
+	gologger.Info().Msgf("%s %s key %s in %s", action, options.Engine, sources.MaskKey(options.Key), options.ProviderFile)
+	return nil
+}
+
+// writeKeystore writes the keys of the provider config and its key sources to an encrypted keystore
+func writeKeystore(options *KeysOptions) error {
+	passphrase := os.Getenv(sources.KeystorePassphraseEnv)
+	if passphrase == "" {
+		return errorutil.NewWithTag("keys", "%s must be set to write a keystore", sources.KeystorePassphraseEnv)
+	}
+	provider := loadProvider(options.ProviderFile)
+	redactLogs(sources.NewRedactor(provider.Secrets()...))
+	if err := sources.WriteKeystore(options.KeystoreWrite, passphrase, provider); err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not write keystore %s", options.KeystoreWrite)
+	}
+	gologger.Info().Msgf("Wrote keystore %s, reference it with keystore: in the provider config and remove the plaintext keys", options.KeystoreWrite)
+	return nil
+}
+
+// listKeys prints the masked keys of all configured engines with their origin
+func listKeys(w io.Writer, provider *sources.Provider) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "ENGINE\tKEY\tORIGIN\n")
+	for _, engine := range provider.Names() {
+		for _, key := range provider.EngineKeys(engine) {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", engine, sources.MaskKey(key), provider.Origin(key))
+		}
+	}
+	_ = tw.Flush()
+}
+
+// printKeyStatuses prints the validation status of all keys
+func printKeyStatuses(w io.Writer, statuses []uncover.KeyStatus) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "ENGINE\tKEY\tORIGIN\tSTATUS\tERROR\n")
+	for _, status := range statuses {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", status.Engine, status.Key, status.Origin, status.Status, status.Error)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,29 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteKeystore(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "provider-config.yaml")
+	require.Nil(t, os.WriteFile(config, []byte("shodan:\n  - shodan-plain-key\n"), 0600))
+	keystore := filepath.Join(dir, "keystore.enc")
+
+	options := ParseKeysOptions([]string{"-keystore-write", keystore, "-pc", config})
+	require.Empty(t, options.Command)
+
+	t.Setenv(sources.KeystorePassphraseEnv, "")
+	require.ErrorContains(t, Keys(options), sources.KeystorePassphraseEnv)
+
+	t.Setenv(sources.KeystorePassphraseEnv, "passphrase")
+	require.Nil(t, Keys(options))
+	provider, err := sources.ReadKeystore(keystore, "passphrase")
+	require.Nil(t, err)
+	require.Contains(t, provider.Shodan, "shodan-plain-key")
+}
@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// serveMetrics exposes the prometheus metrics on /metrics of given address
+func serveMetrics(address string, metrics *sources.Metrics) (*http.Server, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			gologger.Error().Msgf("metrics listener failed: %s\n", err)
+		}
+	}()
+	gologger.Info().Msgf("Serving metrics on http://%s/metrics", listener.Addr())
+	return server, nil
+}
@@ -0,0 +1,461 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// supported notifier types
+const (
+	NotifierWebhook = "webhook"
+	NotifierSlack   = "slack"
+	NotifierDiscord = "discord"
+	NotifierTeams   = "teams"
+)
+
+// default payload templates of chat compatible incoming webhooks
+var defaultNotifierTemplates = map[string]string{
+	NotifierWebhook: `{"results":{{ json .Results }}}`,
+	NotifierSlack:   `{"text":{{ json .Text }}}`,
+	NotifierDiscord: `{"content":{{ json .Text }}}`,
+	NotifierTeams:   `{"text":{{ json .Text }}}`,
+}
+
+var defaultNotifierSummaryTemplates = map[string]string{
+	NotifierWebhook: `{"summary":{{ json .Summary }}}`,
+	NotifierSlack:   `{"text":{{ json .Text }}}`,
+	NotifierDiscord: `{"content":{{ json .Text }}}`,
+	NotifierTeams:   `{"text":{{ json .Text }}}`,
+}
+
+// default maximum payload size (in bytes) of notifier types
+var defaultNotifierMaxSize = map[string]int{
+	NotifierWebhook: 1024 * 1024,
+	NotifierSlack:   3500,
+	NotifierDiscord: 1900,
+	NotifierTeams:   20000,
+}
+
+const (
+	defaultNotifierBatchSize     = 100
+	defaultNotifierFlushInterval = 30
+	defaultNotifierMaxRetries    = 3
+	// notifierQueueBatches is the number of batches queued per target
+	notifierQueueBatches = 10
+	// notifierQueueTimeout is the maximum time notifying waits for a full queue
+	// of a slow target before the result is dropped
+	notifierQueueTimeout = time.Minute
+)
+
+// NotifierOptions contains the configuration of a single notification target
+type NotifierOptions struct {
+	ID              string            `yaml:"id"`
+	Type            string            `yaml:"type"`
+	URL             string            `yaml:"url"`
+	Headers         map[string]string `yaml:"headers"`
+	ResultTemplate  string            `yaml:"result_template"`
+	SummaryTemplate string            `yaml:"summary_template"`
+	BatchSize       int               `yaml:"batch_size"`
+	FlushInterval   int               `yaml:"flush_interval"`
+	MaxRetries      int               `yaml:"max_retries"`
+	MaxSize         int               `yaml:"max_size"`
+	DisableResults  bool              `yaml:"disable_results"`
+	DisableSummary  bool              `yaml:"disable_summary"`
+}
+
+// RunSummary contains the summary of an uncover run sent to notifiers
+type RunSummary struct {
+	Queries          []string             `json:"queries"`
+	Engines          []string             `json:"engines"`
+	StartedAt        time.Time            `json:"started_at"`
+	Duration         string               `json:"duration"`
+	Results          int                  `json:"results"`
+	ResultsPerEngine map[string]int       `json:"results_per_engine"`
+	Errors           int                  `json:"errors"`
+	Dropped          int                  `json:"dropped,omitempty"` // results which could not be queued for the notifier
+	Stats            *sources.StatsReport `json:"stats,omitempty"`
+}
+
+// Text returns the human readable summary used by chat notifiers
+func (summary *RunSummary) Text() string {
+	engines := make([]string, 0, len(summary.ResultsPerEngine))
+	for engine, count := range summary.ResultsPerEngine {
+		engines = append(engines, fmt.Sprintf("%s=%d", engine, count))
+	}
+	sort.Strings(engines)
+	text := fmt.Sprintf("uncover run finished in %s: %d results", summary.Duration, summary.Results)
+	if len(engines) > 0 {
+		text += fmt.Sprintf(" (%s)", strings.Join(engines, ", "))
+	}
+	if summary.Errors > 0 {
+		text += fmt.Sprintf(", %d errors", summary.Errors)
+	}
+	if summary.Dropped > 0 {
+		text += fmt.Sprintf(", %d results not notified", summary.Dropped)
+	}
+	return text
+}
+
+// Notifier pushes results and run summaries to the configured targets
+type Notifier struct {
+	clients []*notifierClient
+}
+
+// NewNotifier creates a notifier for given notification targets
+func NewNotifier(options []*NotifierOptions) (*Notifier, error) {
+	notifier := &Notifier{}
+	for _, opts := range options {
+		client, err := newNotifierClient(opts)
+		if err != nil {
+			return nil, err
+		}
+		notifier.clients = append(notifier.clients, client)
+	}
+	return notifier, nil
+}
+
+// Notify queues a result for all targets accepting results, identical results are
+// notified once. Notifying blocks while the queue of a target is full, the result is
+// dropped for the target if ctx is done or the queue stays full for notifierQueueTimeout
+func (n *Notifier) Notify(ctx context.Context, result sources.Result) {
+	key := notifierResultLine(result)
+	for _, client := range n.clients {
+		if client.options.DisableResults {
+			continue
+		}
+		client.notify(ctx, key, result)
+	}
+}
+
+// Finish flushes pending results and sends the run summary with
+// the number of results dropped for each target
+func (n *Notifier) Finish(summary *RunSummary) {
+	for _, client := range n.clients {
+		client.close()
+		if !client.options.DisableSummary && summary != nil {
+			clientSummary := *summary
+			clientSummary.Dropped = client.droppedResults()
+			if err := client.sendSummary(&clientSummary); err != nil {
+				gologger.Warning().Msgf("could not send summary to %s notifier: %s\n", client.options.ID, err)
+			}
+		}
+	}
+}
+
+// Close flushes pending results without sending a summary
+func (n *Notifier) Close() {
+	for _, client := range n.clients {
+		client.close()
+	}
+}
+
+type notifierClient struct {
+	options         *NotifierOptions
+	httpClient      *retryablehttp.Client
+	resultTemplate  *template.Template
+	summaryTemplate *template.Template
+	queue           chan sources.Result
+	done            chan struct{}
+	// redactor removes the secrets of the webhook url and headers from errors
+	redactor *sources.Redactor
+
+	mu     sync.Mutex
+	closed bool
+	// seen contains the results queued for the target
+	seen map[string]struct{}
+	// dropped is the number of results which could not be queued
+	dropped int
+}
+
+func newNotifierClient(options *NotifierOptions) (*notifierClient, error) {
+	if options.Type == "" {
+		options.Type = NotifierWebhook
+	}
+	if _, ok := defaultNotifierTemplates[options.Type]; !ok {
+		return nil, errorutil.New("unsupported notifier type %s", options.Type)
+	}
+	if options.URL == "" {
+		return nil, errorutil.New("url not specified for %s notifier", options.Type)
+	}
+	if options.ID == "" {
+		options.ID = options.Type
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultNotifierBatchSize
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = defaultNotifierFlushInterval
+	}
+	if options.MaxRetries <= 0 {
+		options.MaxRetries = defaultNotifierMaxRetries
+	}
+	if options.MaxSize <= 0 {
+		options.MaxSize = defaultNotifierMaxSize[options.Type]
+	}
+
+	resultTemplate, err := parseNotifierTemplate(options.ResultTemplate, defaultNotifierTemplates[options.Type])
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("invalid result template of %s notifier", options.ID)
+	}
+	summaryTemplate, err := parseNotifierTemplate(options.SummaryTemplate, defaultNotifierSummaryTemplates[options.Type])
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("invalid summary template of %s notifier", options.ID)
+	}
+
+	retryOptions := retryablehttp.DefaultOptionsSingle
+	retryOptions.RetryMax = options.MaxRetries
+	retryOptions.RetryWaitMin = 500 * time.Millisecond
+	retryOptions.RetryWaitMax = 10 * time.Second
+	retryOptions.CheckRetry = notifierRetryPolicy
+
+	client := &notifierClient{
+		options:         options,
+		httpClient:      retryablehttp.NewClient(retryOptions),
+		resultTemplate:  resultTemplate,
+		summaryTemplate: summaryTemplate,
+		queue:           make(chan sources.Result, options.BatchSize*notifierQueueBatches),
+		done:            make(chan struct{}),
+		redactor:        sources.NewRedactor(notifierSecrets(options)...),
+		seen:            make(map[string]struct{}),
+	}
+	go client.run()
+	return client, nil
+}
+
+// parseNotifierTemplate parses the given template or falls back to the default one.
+// Templates starting with @ are read from the file path that follows.
+func parseNotifierTemplate(value, fallback string) (*template.Template, error) {
+	if value == "" {
+		value = fallback
+	}
+	if strings.HasPrefix(value, "@") {
+		if !fileutil.FileExists(value[1:]) {
+			return nil, errorutil.New("template file %s does not exist", value[1:])
+		}
+		data, err := os.ReadFile(value[1:])
+		if err != nil {
+			return nil, err
+		}
+		value = string(data)
+	}
+	return template.New("notify").Funcs(template.FuncMap{"json": toJSON}).Parse(value)
+}
+
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// notifierRetryPolicy retries on network errors, rate limiting and server errors
+func notifierRetryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		return true, nil
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError, nil
+}
+
+func (c *notifierClient) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(time.Duration(c.options.FlushInterval) * time.Second)
+	defer ticker.Stop()
+
+	batch := make([]sources.Result, 0, c.options.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := c.sendResults(batch); err != nil {
+			gologger.Warning().Msgf("could not send results to %s notifier: %s\n", c.options.ID, err)
+		}
+		batch = make([]sources.Result, 0, c.options.BatchSize)
+	}
+
+	for {
+		select {
+		case result, ok := <-c.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, result)
+			if len(batch) >= c.options.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// notify queues a result which was not queued before, results are marked
+// as seen once they are queued and dropped after the client is closed
+func (c *notifierClient) notify(ctx context.Context, key string, result sources.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.seen[key]; ok {
+		return
+	}
+	if c.closed {
+		c.dropped++
+		return
+	}
+	timer := time.NewTimer(notifierQueueTimeout)
+	defer timer.Stop()
+	select {
+	case c.queue <- result:
+		c.seen[key] = struct{}{}
+	case <-ctx.Done():
+		c.dropped++
+	case <-timer.C:
+		c.dropped++
+	}
+}
+
+// droppedResults returns the number of results which could not be queued
+func (c *notifierClient) droppedResults() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dropped
+}
+
+func (c *notifierClient) close() {
+	c.mu.Lock()
+	closed := c.closed
+	if !closed {
+		c.closed = true
+		close(c.queue)
+	}
+	dropped := c.dropped
+	c.mu.Unlock()
+	<-c.done
+	if !closed && dropped > 0 {
+		gologger.Warning().Msgf("dropped %d results of %s notifier which could not keep up\n", dropped, c.options.ID)
+	}
+}
+
+// sendResults renders and posts a batch of results, splitting it
+// into smaller batches when the payload exceeds the size limit
+func (c *notifierClient) sendResults(batch []sources.Result) error {
+	lines := make([]string, 0, len(batch))
+	for _, result := range batch {
+		lines = append(lines, notifierResultLine(result))
+	}
+	payload, err := renderNotifierTemplate(c.resultTemplate, map[string]interface{}{
+		"Results": batch,
+		"Count":   len(batch),
+		"Text":    strings.Join(lines, "\n"),
+	})
+	if err != nil {
+		return err
+	}
+	if len(payload) > c.options.MaxSize {
+		if len(batch) == 1 {
+			return errorutil.New("payload of %d bytes exceeds max size of %d bytes", len(payload), c.options.MaxSize)
+		}
+		middle := len(batch) / 2
+		if err := c.sendResults(batch[:middle]); err != nil {
+			return err
+		}
+		return c.sendResults(batch[middle:])
+	}
+	return c.post(payload)
+}
+
+func (c *notifierClient) sendSummary(summary *RunSummary) error {
+	payload, err := renderNotifierTemplate(c.summaryTemplate, map[string]interface{}{
+		"Summary": summary,
+		"Text":    summary.Text(),
+	})
+	if err != nil {
+		return err
+	}
+	if len(payload) > c.options.MaxSize {
+		return errorutil.New("payload of %d bytes exceeds max size of %d bytes", len(payload), c.options.MaxSize)
+	}
+	return c.post(payload)
+}
+
+func renderNotifierTemplate(tpl *template.Template, data interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := tpl.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (c *notifierClient) post(payload []byte) error {
+	request, err := retryablehttp.NewRequest(http.MethodPost, c.options.URL, payload)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Uncover - FOSS Project (github.com/projectdiscovery/uncover)")
+	for key, value := range c.options.Headers {
+		request.Header.Set(key, value)
+	}
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return c.redactor.Error(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errorutil.New("unexpected status code %d received", resp.StatusCode)
+	}
+	return nil
+}
+
+// notifierSecrets returns the secrets of the notifier configuration, chat webhook
+// urls contain their secret in the path (e.g. slack /services/T0/B0/secret)
+func notifierSecrets(options *NotifierOptions) []string {
+	var secrets []string
+	if parsed, err := url.Parse(options.URL); err == nil {
+		secrets = append(secrets, strings.Trim(parsed.EscapedPath(), "/"), parsed.RawQuery)
+	}
+	for _, value := range options.Headers {
+		secrets = append(secrets, value)
+	}
+	return secrets
+}
+
+// notifierResultLine returns the single line representation of a result
+func notifierResultLine(result sources.Result) string {
+	var target string
+	switch {
+	case result.Url != "":
+		target = result.Url
+	case result.IP != "" && result.Port > 0:
+		target = result.IpPort()
+	case result.IP != "":
+		target = result.IP
+	case result.Port > 0:
+		target = result.HostPort()
+	default:
+		target = result.Host
+	}
+	if result.Host != "" && !strings.Contains(target, result.Host) {
+		target += fmt.Sprintf(" (%s)", result.Host)
+	}
+	return fmt.Sprintf("[%s] %s", result.Source, target)
+}
@@ -0,0 +1,172 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+type notifierStandIn struct {
+	sync.Mutex
+	payloads []string
+	failures int
+}
+
+func (s *notifierStandIn) handler(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	s.payloads = append(s.payloads, string(body))
+}
+
+func TestNotifierWebhook(t *testing.T) {
+	standIn := &notifierStandIn{failures: 1}
+	ts := httptest.NewServer(http.HandlerFunc(standIn.handler))
+	defer ts.Close()
+
+	notifier, err := NewNotifier([]*NotifierOptions{{URL: ts.URL, BatchSize: 2}})
+	require.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		notifier.Notify(context.Background(), sources.Result{Source: "shodan", IP: "127.0.0.1", Port: 80 + i})
+	}
+	notifier.Finish(&RunSummary{Results: 3, ResultsPerEngine: map[string]int{"shodan": 3}})
+
+	require.Len(t, standIn.payloads, 3)
+	var batch struct {
+		Results []sources.Result `json:"results"`
+	}
+	require.Nil(t, json.Unmarshal([]byte(standIn.payloads[0]), &batch))
+	require.Len(t, batch.Results, 2)
+	require.Nil(t, json.Unmarshal([]byte(standIn.payloads[1]), &batch))
+	require.Len(t, batch.Results, 1)
+
+	var summary struct {
+		Summary RunSummary `json:"summary"`
+	}
+	require.Nil(t, json.Unmarshal([]byte(standIn.payloads[2]), &summary))
+	require.Equal(t, 3, summary.Summary.Results)
+}
+
+func TestNotifierChatSizeLimit(t *testing.T) {
+	standIn := &notifierStandIn{}
+	ts := httptest.NewServer(http.HandlerFunc(standIn.handler))
+	defer ts.Close()
+
+	notifier, err := NewNotifier([]*NotifierOptions{{Type: NotifierDiscord, URL: ts.URL, MaxSize: 100, DisableSummary: true}})
+	require.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		notifier.Notify(context.Background(), sources.Result{Source: "fofa", IP: "127.0.0.1", Port: 8000 + i})
+	}
+	notifier.Finish(nil)
+
+	var lines int
+	for _, payload := range standIn.payloads {
+		require.LessOrEqual(t, len(payload), 100)
+		var message struct {
+			Content string `json:"content"`
+		}
+		require.Nil(t, json.Unmarshal([]byte(payload), &message))
+		lines += len(strings.Split(message.Content, "\n"))
+	}
+	require.Greater(t, len(standIn.payloads), 1)
+	require.Equal(t, 10, lines)
+}
+
+func TestNotifierCustomTemplate(t *testing.T) {
+	standIn := &notifierStandIn{}
+	ts := httptest.NewServer(http.HandlerFunc(standIn.handler))
+	defer ts.Close()
+
+	notifier, err := NewNotifier([]*NotifierOptions{{
+		URL:             ts.URL,
+		ResultTemplate:  `{"count":{{ .Count }}}`,
+		SummaryTemplate: `{"message":{{ json .Text }}}`,
+	}})
+	require.Nil(t, err)
+	notifier.Notify(context.Background(), sources.Result{Source: "shodan", IP: "127.0.0.1", Port: 443})
+	notifier.Finish(&RunSummary{Duration: "1s", Results: 1})
+
+	require.Equal(t, []string{`{"count":1}`, `{"message":"uncover run finished in 1s: 1 results"}`}, standIn.payloads)
+}
+
+func TestNotifierDeduplicate(t *testing.T) {
+	standIn := &notifierStandIn{}
+	ts := httptest.NewServer(http.HandlerFunc(standIn.handler))
+	defer ts.Close()
+
+	notifier, err := NewNotifier([]*NotifierOptions{{URL: ts.URL, ResultTemplate: `{"count":{{ .Count }}}`, DisableSummary: true}})
+	require.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		notifier.Notify(context.Background(), sources.Result{Source: "shodan", IP: "127.0.0.1", Port: 443})
+	}
+	notifier.Finish(nil)
+
+	require.Equal(t, []string{`{"count":1}`}, standIn.payloads)
+}
+
+func TestNotifierBackpressure(t *testing.T) {
+	release := make(chan struct{})
+	standIn := &notifierStandIn{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		standIn.handler(w, r)
+	}))
+	defer ts.Close()
+
+	notifier, err := NewNotifier([]*NotifierOptions{{URL: ts.URL, BatchSize: 1, SummaryTemplate: `{{ json .Summary.Dropped }}`}})
+	require.Nil(t, err)
+	// notifying blocks on the full queue of the stalled target until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	for i := 0; i < notifierQueueBatches+5; i++ {
+		notifier.Notify(ctx, sources.Result{Source: "shodan", IP: "127.0.0.1", Port: i + 1})
+	}
+	require.Greater(t, notifier.clients[0].droppedResults(), 0)
+
+	// dropped results are not marked as seen and can be queued again
+	close(release)
+	require.Eventually(t, func() bool {
+		return len(notifier.clients[0].queue) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	dropped := notifier.clients[0].droppedResults()
+	notifier.Notify(context.Background(), sources.Result{Source: "shodan", IP: "127.0.0.1", Port: notifierQueueBatches + 5})
+	require.Equal(t, dropped, notifier.clients[0].droppedResults())
+
+	notifier.Finish(&RunSummary{})
+	// the summary reports the dropped results
+	require.Equal(t, fmt.Sprint(dropped), standIn.payloads[len(standIn.payloads)-1])
+
+	// notifying after close does not panic
+	notifier.Notify(context.Background(), sources.Result{Source: "shodan", IP: "127.0.0.2", Port: 80})
+	notifier.Close()
+}
+
+func TestNotifierRedactsURL(t *testing.T) {
+	notifier, err := NewNotifier([]*NotifierOptions{{Type: NotifierSlack, URL: "http://127.0.0.1:1/services/T000/B000/secrettoken", MaxRetries: 1}})
+	require.Nil(t, err)
+	defer notifier.Close()
+	err = notifier.clients[0].post([]byte(`{}`))
+	require.NotNil(t, err)
+	require.NotContains(t, err.Error(), "secrettoken")
+	require.Contains(t, err.Error(), sources.Redacted)
+}
+
+func TestNotifierMissingTemplateFile(t *testing.T) {
+	_, err := NewNotifier([]*NotifierOptions{{URL: "http://127.0.0.1", ResultTemplate: "@/does/not/exist.json"}})
+	require.NotNil(t, err)
+}
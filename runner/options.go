@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
+	"time"
 
 	"errors"
 
@@ -31,6 +33,10 @@ type Options struct {
 	Query                goflags.StringSlice
 	Engine               goflags.StringSlice
 	AwesomeSearchQueries goflags.StringSlice
+	Facets               goflags.StringSlice
+	Count                bool
+	CVE                  goflags.StringSlice
+	CVEGroup             bool
 	ConfigFile           string
 	ProviderFile         string
 	OutputFile           string
@@ -42,10 +48,15 @@ type Options struct {
 	Verbose              bool
 	NoColor              bool
 	Timeout              int
-	RateLimit            int
+	RateLimit            goflags.StringSlice
 	RateLimitMinute      int
 	Retries              int
 	Proxy                string
+	CACert               string
+	Insecure             bool
+	UserAgent            string
+	Headers              goflags.StringSlice
+	EngineOptions        goflags.StringSlice
 	Shodan               goflags.StringSlice
 	ShodanIdb            goflags.StringSlice
 	Fofa                 goflags.StringSlice
@@ -65,6 +76,19 @@ type Options struct {
 	GreyNoise            goflags.StringSlice
 	NerdyData            goflags.StringSlice
 	DisableUpdateCheck   bool
+	DisableNotify        bool
+	Stats                bool
+	StatsJSON            string
+	ProgressInterval     time.Duration
+	MetricsAddr          string
+	TraceEndpoint        string
+	TraceFile            string
+	DebugRequest         bool
+	DebugResponse        bool
+	LogFormat            string
+	Notify               []*NotifierOptions `yaml:"notify"`
+	// EngineHTTP contains the http client configuration of engines from the http section of the flag config file
+	EngineHTTP map[string]sources.HTTPOptions `yaml:"http"`
 }
 
 // ParseOptions parses the command line flags provided by a user
@@ -77,6 +101,7 @@ func ParseOptions() *Options {
 		flagSet.StringSliceVarP(&options.Query, "query", "q", nil, "search query, supports: stdin,file,config input (example: -q 'example query', -q 'query.txt')", goflags.FileStringSliceOptions),
 		flagSet.StringSliceVarP(&options.Engine, "engine", "e", nil, "search engine to query (shodan,shodan-idb,fofa,censys,quake,hunter,zoomeye,netlas,publicwww,criminalip,hunterhow,google,odin,binaryedge,onyphe,driftnet,greynoise,nerdydata) (default shodan)", goflags.FileNormalizedStringSliceOptions),
 		flagSet.StringSliceVarP(&options.AwesomeSearchQueries, "awesome-search-queries", "asq", nil, "use awesome search queries to discover exposed assets on the internet (example: -asq 'jira')", goflags.FileStringSliceOptions),
+		flagSet.StringSliceVar(&options.CVE, "cve", nil, "search assets vulnerable to cve with the cve query of each engine (shodan,netlas,greynoise) (example: -cve CVE-2021-44228)", goflags.FileCommaSeparatedStringSliceOptions),
 	)
 
 	flagSet.CreateGroup("search-engine", "Search-Engine",
@@ -102,12 +127,17 @@ func ParseOptions() *Options {
 
 	flagSet.CreateGroup("config", "Config",
 		flagSet.StringVarP(&options.ProviderFile, "provider", "pc", sources.DefaultProviderConfigLocation, "provider configuration file"),
+		flagSet.StringSliceVarP(&options.EngineOptions, "engine-option", "eo", nil, "engine specific query option in engine.option=value format (example: -eo fofa.full=true,fofa.size=500)", goflags.CommaSeparatedStringSliceOptions),
 		flagSet.StringVar(&options.ConfigFile, "config", defaultConfigLocation, "flag configuration file"),
 		flagSet.IntVar(&options.Timeout, "timeout", 30, "timeout in seconds"),
-		flagSet.IntVarP(&options.RateLimit, "rate-limit", "rl", 0, "maximum number of http requests to send per second"),
-		flagSet.IntVarP(&options.RateLimitMinute, "rate-limit-minute", "rlm", 0, "maximum number of requests to send per minute"),
+		flagSet.StringSliceVarP(&options.RateLimit, "rate-limit", "rl", nil, "maximum number of http requests per second of all engines, count/unit or engine=count/unit per engine (example: -rl 10, -rl shodan=2/s,fofa=30/m)", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.IntVarP(&options.RateLimitMinute, "rate-limit-minute", "rlm", 0, "maximum number of requests per minute of all engines"),
 		flagSet.IntVar(&options.Retries, "retry", 2, "number of times to retry a failed request"),
-		flagSet.StringVar(&options.Proxy, "proxy", "", "http proxy to use with uncover"),
+		flagSet.StringVar(&options.Proxy, "proxy", "", "http or socks5 proxy to use with uncover (example: socks5://127.0.0.1:1080)"),
+		flagSet.StringVarP(&options.CACert, "ca-cert", "ca", "", "pem file of ca certificates to trust in addition to the system ones"),
+		flagSet.BoolVar(&options.Insecure, "insecure", false, "disable tls certificate verification of engine apis"),
+		flagSet.StringVarP(&options.UserAgent, "user-agent", "ua", "", "custom user agent to send to engine apis"),
+		flagSet.StringSliceVarP(&options.Headers, "header", "H", nil, "custom header to send to engine apis (example: -H 'X-Team: recon')", goflags.StringSliceOptions),
 	)
 
 	flagSet.CreateGroup("update", "Update",
@@ -120,14 +150,27 @@ func ParseOptions() *Options {
 		flagSet.StringVarP(&options.OutputFields, "field", "f", "ip:port", "field to display in output (ip,port,host)"),
 		flagSet.BoolVarP(&options.JSON, "json", "j", false, "write output in JSONL(ines) format"),
 		flagSet.BoolVarP(&options.Raw, "raw", "r", false, "write raw output as received by the remote api"),
+		flagSet.BoolVarP(&options.CVEGroup, "cve-group", "cg", false, "write the assets of results grouped by the cves they are vulnerable to"),
+		flagSet.BoolVarP(&options.Count, "count", "cnt", false, "write the total number of results per engine and query without retrieving them"),
+		flagSet.StringSliceVarP(&options.Facets, "facets", "fc", nil, "write the most common values of facet fields per engine instead of results, field:size sets the number of values (example: -facets port,country:20)", goflags.CommaSeparatedStringSliceOptions),
 		flagSet.IntVarP(&options.Limit, "limit", "l", 100, "limit the number of results to return"),
 		flagSet.BoolVarP(&options.NoColor, "no-color", "nc", false, "disable colors in output"),
+		flagSet.BoolVarP(&options.DisableNotify, "disable-notify", "dn", false, "disable notifications configured in the flag configuration file"),
 	)
 
 	flagSet.CreateGroup("debug", "Debug",
 		flagSet.BoolVar(&options.Silent, "silent", false, "show only results in output"),
 		flagSet.CallbackVar(versionCallback, "version", "show version of the project"),
 		flagSet.BoolVar(&options.Verbose, "v", false, "show verbose output"),
+		flagSet.BoolVarP(&options.DebugRequest, "debug-req", "dreq", false, "log http requests sent to engines (credentials are redacted)"),
+		flagSet.BoolVarP(&options.DebugResponse, "debug-resp", "dresp", false, "log http responses received from engines (credentials are redacted)"),
+		flagSet.StringVarP(&options.LogFormat, "log-format", "lf", "text", "format of log messages (text,json)"),
+		flagSet.BoolVar(&options.Stats, "stats", false, "display per engine and per query statistics at the end of the run"),
+		flagSet.StringVarP(&options.StatsJSON, "stats-json", "sj", "", "file to write run statistics in JSON format"),
+		flagSet.DurationVarP(&options.ProgressInterval, "progress-interval", "pi", 2*time.Second, "refresh interval of live progress display shown on terminal unless silent"),
+		flagSet.StringVarP(&options.MetricsAddr, "metrics-addr", "ma", "", "address to expose prometheus metrics on /metrics (example: 127.0.0.1:9090)"),
+		flagSet.StringVarP(&options.TraceEndpoint, "trace-endpoint", "te", "", "otlp http endpoint to export opentelemetry traces to (example: http://127.0.0.1:4318)"),
+		flagSet.StringVarP(&options.TraceFile, "trace-file", "tf", "", "file to write opentelemetry traces in JSON format"),
 	)
 
 	if err := flagSet.Parse(); err != nil {
@@ -152,8 +195,16 @@ func ParseOptions() *Options {
 		_ = options.loadConfigFrom(options.ConfigFile)
 	}
 
-	if options.ProviderFile != sources.DefaultProviderConfigLocation {
-		sources.DefaultProviderConfigLocation = options.ProviderFile
+	if err := options.loadNotifyConfigFrom(options.ConfigFile); err != nil {
+		gologger.Warning().Msgf("could not load notify config: %s\n", err)
+	}
+
+	if err := options.loadHTTPConfigFrom(options.ConfigFile); err != nil {
+		gologger.Fatal().Msgf("could not load http config: %s\n", err)
+	}
+
+	if options.ProviderFile == sources.DefaultProviderConfigLocation {
+		createDefaultProviderConfig()
 	}
 
 	if genericutil.EqualsAll(0,
@@ -217,6 +268,16 @@ func (options *Options) configureOutput() {
 	if options.Silent {
 		gologger.DefaultLogger.SetMaxLevel(levels.LevelSilent)
 	}
+	if options.DebugRequest || options.DebugResponse {
+		gologger.DefaultLogger.SetMaxLevel(levels.LevelDebug)
+	}
+	switch options.LogFormat {
+	case "json":
+		gologger.DefaultLogger.SetFormatter(&formatter.JSON{})
+	case "text", "":
+	default:
+		gologger.Fatal().Msgf("invalid log format %s, supported formats are text and json", options.LogFormat)
+	}
 }
 
 func (options *Options) loadConfigFrom(location string) error {
@@ -226,6 +287,80 @@ func (options *Options) loadConfigFrom(location string) error {
 	return fileutil.Unmarshal(fileutil.YAML, []byte(location), options)
 }
 
+// loadNotifyConfigFrom loads the notification targets from the notify section of the flag config file
+func (options *Options) loadNotifyConfigFrom(location string) error {
+	if !fileutil.FileExists(location) {
+		return nil
+	}
+	notifyConfig := struct {
+		Notify []*NotifierOptions `yaml:"notify"`
+	}{}
+	if err := fileutil.Unmarshal(fileutil.YAML, []byte(location), &notifyConfig); err != nil {
+		return err
+	}
+	options.Notify = notifyConfig.Notify
+	return nil
+}
+
+// loadHTTPConfigFrom loads the per engine http client configuration from the http section of the flag config file
+func (options *Options) loadHTTPConfigFrom(location string) error {
+	if !fileutil.FileExists(location) {
+		return nil
+	}
+	httpConfig := struct {
+		HTTP map[string]sources.HTTPOptions `yaml:"http"`
+	}{}
+	if err := fileutil.Unmarshal(fileutil.YAML, []byte(location), &httpConfig); err != nil {
+		return err
+	}
+	options.EngineHTTP = httpConfig.HTTP
+	return nil
+}
+
+// httpOptions returns the http client configuration of all engines from the flags
+func (options *Options) httpOptions() (sources.HTTPOptions, error) {
+	httpOptions := sources.HTTPOptions{
+		CACert:    options.CACert,
+		Insecure:  options.Insecure,
+		UserAgent: options.UserAgent,
+	}
+	for _, header := range options.Headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return httpOptions, errorutil.New("invalid header %q, expected format is 'Name: value'", header)
+		}
+		if httpOptions.Headers == nil {
+			httpOptions.Headers = make(map[string]string)
+		}
+		httpOptions.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return httpOptions, nil
+}
+
+// engineOptions returns the query options of engines from engine.option=value values,
+// values without = continue the comma separated value of the previous option (e.g. quake.include=ip,port)
+func (options *Options) engineOptions() (map[string]map[string]string, error) {
+	engineOptions := make(map[string]map[string]string)
+	var engine, option string
+	for _, value := range options.EngineOptions {
+		name, optionValue, ok := strings.Cut(value, "=")
+		if !ok && option != "" {
+			engineOptions[engine][option] += "," + strings.TrimSpace(value)
+			continue
+		}
+		var hasEngine bool
+		engine, option, hasEngine = strings.Cut(strings.ToLower(strings.TrimSpace(name)), ".")
+		if !ok || !hasEngine || engine == "" || option == "" {
+			return nil, errorutil.New("invalid engine option %q, expected format is engine.option=value", value)
+		}
+		if engineOptions[engine] == nil {
+			engineOptions[engine] = make(map[string]string)
+		}
+		engineOptions[engine][option] = strings.TrimSpace(optionValue)
+	}
+	return engineOptions, nil
+}
+
 // validateOptions validates the configuration options passed
 func (options *Options) validateOptions() error {
 	// Check if domain, list of domains, or stdin info was provided.
@@ -249,7 +384,8 @@ func (options *Options) validateOptions() error {
 		len(options.Onyphe),
 		len(options.Driftnet),
 		len(options.GreyNoise),
-		len(options.NerdyData)) {
+		len(options.NerdyData),
+		len(options.CVE)) {
 		return errors.New("no query provided")
 	}
 
@@ -258,6 +394,14 @@ func (options *Options) validateOptions() error {
 		return errors.New("both verbose and silent mode specified")
 	}
 
+	if options.Count && len(options.Facets) > 0 {
+		return errors.New("both count and facets mode specified")
+	}
+
+	if len(options.CVE) > 0 && (options.Count || len(options.Facets) > 0) {
+		return errors.New("cve search is not supported in count and facets mode")
+	}
+
 	// Validate threads and options
 	if genericutil.EqualsAll(0,
 		len(options.Engine),
@@ -282,9 +426,37 @@ func (options *Options) validateOptions() error {
 		return errors.New("no engine specified")
 	}
 
+	if len(options.CVE) > 0 {
+		var unsupported []string
+		for _, engine := range options.Engine {
+			if !sources.SupportsCVE(engine) {
+				unsupported = append(unsupported, engine)
+			}
+		}
+		if len(unsupported) > 0 {
+			return errorutil.New("cve search is not supported by %s", strings.Join(unsupported, ","))
+		}
+	}
+
 	return nil
 }
 
+// createDefaultProviderConfig creates the uncover config dir and
+// an empty provider config file at the default location if missing
+func createDefaultProviderConfig() {
+	if !fileutil.FolderExists(sources.UncoverConfigDir) {
+		if err := fileutil.CreateFolder(sources.UncoverConfigDir); err != nil {
+			gologger.Warning().Msgf("couldn't create uncover config dir: %s\n", err)
+			return
+		}
+	}
+	if !fileutil.FileExists(sources.DefaultProviderConfigLocation) {
+		if err := fileutil.Marshal(fileutil.YAML, []byte(sources.DefaultProviderConfigLocation), sources.Provider{}); err != nil {
+			gologger.Warning().Msgf("couldn't write provider default file: %s\n", err)
+		}
+	}
+}
+
 func versionCallback() {
 	gologger.Info().Msgf("Current Version: %s\n", version)
 	gologger.Info().Msgf("Uncover ConfigDir: %s\n", folderutil.AppConfigDirOrDefault(".uncover-config", "uncover"))
@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineOptions(t *testing.T) {
+	options := &Options{EngineOptions: []string{"FOFA.full=true", "fofa.size= 500", "quake.latest=false", "quake.include=ip", "port", "hunter.is_web=1"}}
+	engineOptions, err := options.engineOptions()
+	require.Nil(t, err)
+	require.Equal(t, map[string]map[string]string{
+		"fofa":   {"full": "true", "size": "500"},
+		"quake":  {"latest": "false", "include": "ip,port"},
+		"hunter": {"is_web": "1"},
+	}, engineOptions)
+
+	for _, value := range []string{"full=true", "fofa.full", ".full=true", "fofa.=true"} {
+		_, err = (&Options{EngineOptions: []string{value}}).engineOptions()
+		require.ErrorContains(t, err, "invalid engine option", value)
+	}
+}
+
+func TestValidateCVEEngines(t *testing.T) {
+	options := &Options{CVE: []string{"CVE-2021-44228"}, Engine: []string{"shodan", "netlas"}}
+	require.Nil(t, options.validateOptions())
+
+	options.Engine = append(options.Engine, "criminalip", "fofa")
+	require.ErrorContains(t, options.validateOptions(), "cve search is not supported by criminalip,fofa")
+}
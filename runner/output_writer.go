@@ -54,19 +54,23 @@ func (o *OutputWriter) findDuplicate(data string, markAsSeen bool) bool {
 }
 
 // WriteString writes the string taken as input using only
-func (o *OutputWriter) WriteString(data string) {
+// and returns false if it was skipped as duplicate
+func (o *OutputWriter) WriteString(data string) bool {
 	if o.findDuplicate(data, true) {
-		return
+		return false
 	}
 	o.Write([]byte(data))
+	return true
 }
 
 // WriteJsonData writes the result taken as input in JSON format
-func (o *OutputWriter) WriteJsonData(data sources.Result) {
+// and returns false if it was skipped as duplicate
+func (o *OutputWriter) WriteJsonData(data sources.Result) bool {
 	if o.findDuplicate(fmt.Sprintf("%s:%d", data.IP, data.Port), true) {
-		return
+		return false
 	}
 	o.Write([]byte(data.JSON()))
+	return true
 }
 
 // Close closes the output writers
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// progressReporter periodically renders per engine progress to stderr
+type progressReporter struct {
+	stats    *sources.Stats
+	limit    int
+	interval time.Duration
+	writer   io.Writer
+	// terminal redraws the progress block in place instead of appending it
+	terminal bool
+
+	lines int
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newProgressReporter(stats *sources.Stats, limit int, interval time.Duration) *progressReporter {
+	return &progressReporter{
+		stats:    stats,
+		limit:    limit,
+		interval: interval,
+		writer:   os.Stderr,
+		terminal: isTerminal(os.Stderr),
+		done:     make(chan struct{}),
+	}
+}
+
+// progressEnabled returns true if live progress is displayed, it is shown by default
+// when stderr is a terminal and hidden in silent mode
+func progressEnabled(options *Options, terminal bool) bool {
+	return terminal && !options.Silent && options.ProgressInterval > 0
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *progressReporter) start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				p.clear()
+				return
+			case <-ticker.C:
+				p.render()
+			}
+		}
+	}()
+}
+
+func (p *progressReporter) stop() {
+	close(p.done)
+	p.wg.Wait()
+}
+
+// clear removes the last rendered block from the terminal
+func (p *progressReporter) clear() {
+	if p.terminal && p.lines > 0 {
+		_, _ = fmt.Fprintf(p.writer, "\033[%dA\033[J", p.lines)
+	}
+	p.lines = 0
+}
+
+func (p *progressReporter) render() {
+	report := p.stats.Report()
+	lines := progressLines(report, p.limit)
+	p.clear()
+	_, _ = fmt.Fprint(p.writer, strings.Join(lines, "\n")+"\n")
+	p.lines = len(lines)
+}
+
+// progressLines returns a single progress line per engine of the report
+func progressLines(report *sources.StatsReport, limit int) []string {
+	lines := make([]string, 0, len(report.Engines))
+	for _, engine := range report.Engines {
+		var expected, queries, finished int
+		for _, query := range report.Queries {
+			if query.Engine != engine.Engine {
+				continue
+			}
+			queries++
+			if query.Finished {
+				finished++
+				expected += query.Results
+				continue
+			}
+			switch {
+			case query.Total > 0 && (limit <= 0 || query.Total < limit):
+				expected += query.Total
+			case limit > 0:
+				expected += limit
+			}
+		}
+
+		status := "running"
+		if queries > 0 && finished == queries {
+			status = "done"
+		}
+		line := fmt.Sprintf("[%s] %s | pages: %d | results: %d", engine.Engine, status, engine.Requests, engine.Results)
+		if expected > 0 {
+			line += fmt.Sprintf("/%d", expected)
+		}
+		if engine.Total > 0 {
+			line += fmt.Sprintf(" (total: %d)", engine.Total)
+		}
+		if engine.Errors > 0 {
+			line += fmt.Sprintf(" | errors: %d", engine.Errors)
+		}
+		if engine.RateLimitWait > 0 || engine.RateLimitWaiting > 0 {
+			line += fmt.Sprintf(" | ratelimit wait: %s", engine.RateLimitWait.Truncate(time.Second))
+			if engine.RateLimitWaiting > 0 {
+				line += " (waiting)"
+			}
+		}
+		if status == "running" && engine.Results > 0 && expected > engine.Results {
+			eta := time.Duration(float64(engine.Duration) / float64(engine.Results) * float64(expected-engine.Results))
+			line += fmt.Sprintf(" | eta: %s", eta.Round(time.Second))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
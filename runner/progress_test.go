@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressLines(t *testing.T) {
+	report := &sources.StatsReport{
+		Engines: []sources.EngineStats{
+			{Engine: "shodan", Requests: 2, Results: 200, Total: 5000, Duration: 10 * time.Second},
+			{Engine: "fofa", Requests: 1, Results: 10},
+		},
+		Queries: []sources.QueryStats{
+			{Engine: "shodan", Query: "a", Results: 200, Total: 5000},
+			{Engine: "fofa", Query: "a", Results: 10, Finished: true},
+		},
+	}
+	lines := progressLines(report, 1000)
+	require.Equal(t, []string{
+		"[shodan] running | pages: 2 | results: 200/1000 (total: 5000) | eta: 40s",
+		"[fofa] done | pages: 1 | results: 10/10",
+	}, lines)
+}
+
+func TestProgressEnabled(t *testing.T) {
+	options := &Options{ProgressInterval: 2 * time.Second}
+	require.True(t, progressEnabled(options, true))
+	// progress is only rendered on terminals
+	require.False(t, progressEnabled(options, false))
+	options.Silent = true
+	require.False(t, progressEnabled(options, true))
+}
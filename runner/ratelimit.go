@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// setRateLimits sets the rate limits of opts from the rate limit flags, values are
+// count/unit for all engines (10, 10/m) or engine=count/unit for one engine (shodan=2/s)
+func setRateLimits(opts *uncover.Options, values []string, rateLimitMinute int) error {
+	for _, value := range values {
+		engine, limit, ok := strings.Cut(value, "=")
+		if !ok {
+			engine, limit = "", value
+		}
+		rateLimit, err := sources.ParseRateLimit(limit)
+		if err != nil {
+			return err
+		}
+		if engine == "" {
+			opts.RateLimit = rateLimit.MaxCount
+			opts.RateLimitUnit = rateLimit.Duration
+			continue
+		}
+		if opts.RateLimits == nil {
+			opts.RateLimits = make(map[string]sources.RateLimit)
+		}
+		opts.RateLimits[strings.ToLower(strings.TrimSpace(engine))] = rateLimit
+	}
+	if opts.RateLimit == 0 && rateLimitMinute > 0 {
+		opts.RateLimit = uint(rateLimitMinute)
+		opts.RateLimitUnit = time.Minute
+	}
+	return nil
+}
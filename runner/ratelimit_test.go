@@ -0,0 +1,28 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRateLimits(t *testing.T) {
+	opts := &uncover.Options{}
+	require.Nil(t, setRateLimits(opts, []string{"10", "shodan=2/s", "Fofa=30/m"}, 60))
+	require.Equal(t, uint(10), opts.RateLimit)
+	require.Equal(t, time.Second, opts.RateLimitUnit)
+	require.Equal(t, map[string]sources.RateLimit{
+		"shodan": {MaxCount: 2, Duration: time.Second},
+		"fofa":   {MaxCount: 30, Duration: time.Minute},
+	}, opts.RateLimits)
+
+	opts = &uncover.Options{}
+	require.Nil(t, setRateLimits(opts, nil, 60))
+	require.Equal(t, uint(60), opts.RateLimit)
+	require.Equal(t, time.Minute, opts.RateLimitUnit)
+
+	require.Error(t, setRateLimits(&uncover.Options{}, []string{"shodan=fast"}, 0))
+}
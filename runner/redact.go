@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"runtime/debug"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/gologger/writer"
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// redactingWriter scrubs provider keys from log messages before writing them
+type redactingWriter struct {
+	writer   writer.Writer
+	redactor *sources.Redactor
+}
+
+func (w *redactingWriter) Write(data []byte, level levels.Level) {
+	w.writer.Write([]byte(w.redactor.Redact(string(data))), level)
+}
+
+// redactLogs scrubs the keys known to given redactor from all log messages
+func redactLogs(redactor *sources.Redactor) {
+	gologger.DefaultLogger.SetWriter(&redactingWriter{writer: writer.NewCLI(), redactor: redactor})
+}
+
+// HandlePanic recovers a panic of the calling goroutine and logs it,
+// including its stack trace, with provider keys redacted
+func HandlePanic() {
+	if r := recover(); r != nil {
+		gologger.Fatal().Msgf("uncover crashed: %v\n%s", r, debug.Stack())
+	}
+}
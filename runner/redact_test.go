@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingWriter struct {
+	lines []string
+}
+
+func (w *recordingWriter) Write(data []byte, _ levels.Level) {
+	w.lines = append(w.lines, string(data))
+}
+
+func TestRedactingWriter(t *testing.T) {
+	recorder := &recordingWriter{}
+	writer := &redactingWriter{writer: recorder, redactor: sources.NewRedactor("censys-token", "censys-org")}
+	writer.Write([]byte("[censys] unauthorized censys-token:censys-org"), levels.LevelWarning)
+	require.Equal(t, []string{"[censys] unauthorized REDACTED:REDACTED"}, recorder.lines)
+}
@@ -3,8 +3,10 @@ package runner
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/uncover"
@@ -19,6 +21,9 @@ type Runner struct {
 	options      *Options
 	service      *uncover.Service
 	outputWriter *OutputWriter
+	notifier     *Notifier
+	metrics      *http.Server
+	tracing      *tracing
 }
 
 // NewRunner creates a new runner struct instance by parsing
@@ -29,16 +34,36 @@ func NewRunner(options *Options) (*Runner, error) {
 	appendAllQueries(options)
 
 	opts := uncover.Options{
-		Agents:  options.Engine,
-		Queries: options.Query,
-		Limit:   options.Limit,
-		Proxy:   options.Proxy,
+		Agents:        options.Engine,
+		Queries:       options.Query,
+		Limit:         options.Limit,
+		MaxRetry:      options.Retries,
+		Timeout:       options.Timeout,
+		Proxy:         options.Proxy,
+		DebugRequest:  options.DebugRequest,
+		DebugResponse: options.DebugResponse,
+	}
+	if err := setRateLimits(&opts, options.RateLimit, options.RateLimitMinute); err != nil {
+		return nil, err
+	}
+	httpOptions, err := options.httpOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts.HTTP = httpOptions
+	opts.EngineHTTP = options.EngineHTTP
+	if opts.EngineOptions, err = options.engineOptions(); err != nil {
+		return nil, err
+	}
+	if options.ProviderFile != sources.DefaultProviderConfigLocation {
+		opts.ProviderFile = options.ProviderFile
 	}
 	service, err := uncover.New(&opts)
 	if err != nil {
 		return nil, err
 	}
 	runner.service = service
+	redactLogs(service.Session.Redactor)
 
 	runner.outputWriter, err = NewOutputWriter()
 	if err != nil {
@@ -55,22 +80,72 @@ func NewRunner(options *Options) (*Runner, error) {
 		}
 		runner.outputWriter.AddWriters(outputFile)
 	}
+	if len(options.Notify) > 0 && !options.DisableNotify {
+		runner.notifier, err = NewNotifier(options.Notify)
+		if err != nil {
+			return nil, errorutil.NewWithErr(err).Msgf("could not create notifier")
+		}
+	}
+	if options.TraceEndpoint != "" || options.TraceFile != "" {
+		runner.tracing, err = setupTracing(context.Background(), options.TraceEndpoint, options.TraceFile)
+		if err != nil {
+			return nil, errorutil.NewWithErr(err).Msgf("could not setup tracing")
+		}
+	}
+	if options.MetricsAddr != "" {
+		runner.metrics, err = serveMetrics(options.MetricsAddr, service.Metrics)
+		if err != nil {
+			return nil, errorutil.NewWithErr(err).Msgf("could not start metrics listener")
+		}
+	}
 	return runner, nil
 }
 
 // RunEnumeration runs the subdomain enumeration flow on the targets specified
 func (r *Runner) Run(ctx context.Context) error {
+	switch {
+	case r.options.Count:
+		return r.runCount(ctx)
+	case len(r.options.Facets) > 0:
+		return r.runFacets(ctx)
+	}
+	summary := &RunSummary{
+		Queries:          r.options.Query,
+		Engines:          r.options.Engine,
+		StartedAt:        time.Now(),
+		ResultsPerEngine: make(map[string]int),
+	}
+	var groups *cveGroups
+	if r.options.CVEGroup {
+		groups = newCVEGroups()
+	}
 	resultCallback := func(result sources.Result) {
+		if result.Error != nil {
+			summary.Errors++
+		} else {
+			summary.Results++
+			summary.ResultsPerEngine[result.Source]++
+			if r.notifier != nil {
+				r.notifier.Notify(ctx, result)
+			}
+		}
+
 		optionFields := r.options.OutputFields
 		switch {
 		case result.Error != nil:
 			gologger.Warning().Label(result.Source).Msgf("%s\n", result.Error.Error())
+		case groups != nil:
+			groups.add(result)
 		case r.options.JSON:
 			gologger.Verbose().Label(result.Source).Msgf("%s\n", result.JSON())
-			r.outputWriter.WriteJsonData(result)
+			if !r.outputWriter.WriteJsonData(result) {
+				r.addDuplicate(result.Source)
+			}
 		case r.options.Raw:
 			gologger.Verbose().Label(result.Source).Msgf("%s\n", result.RawData())
-			r.outputWriter.WriteString(result.RawData())
+			if !r.outputWriter.WriteString(result.RawData()) {
+				r.addDuplicate(result.Source)
+			}
 		default:
 			port := fmt.Sprint(result.Port)
 			replacer := strings.NewReplacer(
@@ -87,19 +162,76 @@ func (r *Runner) Run(ctx context.Context) error {
 			if result.Host != "" || r.options.OutputFile != "" {
 				searchFor = append(searchFor, result.Host)
 			}
-			if stringsutil.ContainsAny(outData, searchFor...) && !r.outputWriter.findDuplicate(outData, false) {
-				if r.options.Verbose {
-					gologger.Info().Label(result.Source).Msg(outData)
-				}
-				r.outputWriter.WriteString(outData)
+			if !stringsutil.ContainsAny(outData, searchFor...) {
+				return
+			}
+			if r.outputWriter.findDuplicate(outData, false) {
+				r.addDuplicate(result.Source)
+				return
+			}
+			if r.options.Verbose {
+				gologger.Info().Label(result.Source).Msg(outData)
 			}
+			r.outputWriter.WriteString(outData)
 		}
 	}
-	return r.service.ExecuteWithCallback(ctx, resultCallback)
+	var progress *progressReporter
+	if progressEnabled(r.options, isTerminal(os.Stderr)) {
+		progress = newProgressReporter(r.service.Stats, r.options.Limit, r.options.ProgressInterval)
+		progress.start()
+	}
+	var err error
+	// without queries only the cve queries are executed
+	if len(r.options.Query) > 0 || len(r.options.CVE) == 0 {
+		err = r.service.ExecuteWithCallback(ctx, resultCallback)
+	}
+	if err == nil && len(r.options.CVE) > 0 {
+		err = r.executeCVEs(ctx, resultCallback)
+	}
+	if progress != nil {
+		progress.stop()
+	}
+	if groups != nil {
+		groups.write(r.outputWriter, r.options.JSON)
+	}
+	report := r.service.Stats.Report()
+	if r.notifier != nil {
+		summary.Duration = time.Since(summary.StartedAt).Round(time.Millisecond).String()
+		summary.Stats = report
+		r.notifier.Finish(summary)
+	}
+	if r.options.Stats {
+		printStats(os.Stderr, report)
+	}
+	if r.options.StatsJSON != "" {
+		if statsErr := writeStatsJSON(r.options.StatsJSON, report); statsErr != nil {
+			gologger.Error().Msgf("could not write stats to %s: %s\n", r.options.StatsJSON, statsErr)
+		}
+	}
+	return err
+}
+
+// addDuplicate records a result dropped by the output deduplication cache
+func (r *Runner) addDuplicate(engine string) {
+	r.service.Stats.AddDuplicate(engine)
+	r.service.Metrics.AddCacheHit(engine)
 }
 
 // Close closes its resources
 func (r *Runner) Close() {
+	if r.metrics != nil {
+		_ = r.metrics.Close()
+	}
+	if r.tracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := r.tracing.Close(ctx); err != nil {
+			gologger.Warning().Msgf("could not export traces: %s\n", err)
+		}
+		cancel()
+	}
+	if r.notifier != nil {
+		r.notifier.Close()
+	}
 	if r.outputWriter != nil {
 		r.outputWriter.Close()
 	}
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/projectdiscovery/goflags"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/gologger/formatter"
+	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/server"
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// ServeOptions contains the configuration options of the api server mode
+type ServeOptions struct {
+	Listen          string
+	AuthToken       string
+	MaxJobs         int
+	JobTTL          time.Duration
+	MaxLimit        int
+	MaxJobResults   int
+	MaxFinishedJobs int
+	ProviderFile    string
+	Timeout         int
+	RateLimit       goflags.StringSlice
+	RateLimitMinute int
+	Retries         int
+	Proxy           string
+	Silent          bool
+	Verbose         bool
+	NoColor         bool
+}
+
+// ParseServeOptions parses the command line flags of the serve subcommand
+func ParseServeOptions(args []string) *ServeOptions {
+	options := &ServeOptions{}
+	flagSet := goflags.NewFlagSet()
+	flagSet.SetDescription(`run uncover as a REST api server sharing provider keys and rate limits across jobs.`)
+
+	flagSet.CreateGroup("server", "Server",
+		flagSet.StringVarP(&options.Listen, "listen", "l", "127.0.0.1:8080", "address to listen on"),
+		flagSet.StringVarP(&options.AuthToken, "auth-token", "at", os.Getenv("UNCOVER_API_TOKEN"), "bearer token required on api requests (default $UNCOVER_API_TOKEN)"),
+		flagSet.IntVarP(&options.MaxJobs, "max-jobs", "mj", server.DefaultMaxJobs, "maximum number of jobs to run concurrently"),
+		flagSet.DurationVarP(&options.JobTTL, "job-ttl", "jt", server.DefaultJobTTL, "duration to keep finished jobs and their results"),
+		flagSet.IntVarP(&options.MaxLimit, "max-limit", "ml", server.DefaultMaxLimit, "maximum limit of results of a job per query and engine"),
+		flagSet.IntVarP(&options.MaxJobResults, "max-job-results", "mjr", server.DefaultMaxResults, "maximum number of results kept per job, jobs are stopped once reached"),
+		flagSet.IntVarP(&options.MaxFinishedJobs, "max-finished-jobs", "mfj", server.DefaultMaxFinishedJobs, "maximum number of finished jobs kept in memory"),
+	)
+
+	flagSet.CreateGroup("config", "Config",
+		flagSet.StringVarP(&options.ProviderFile, "provider", "pc", sources.DefaultProviderConfigLocation, "provider configuration file"),
+		flagSet.IntVar(&options.Timeout, "timeout", 30, "timeout in seconds"),
+		flagSet.StringSliceVarP(&options.RateLimit, "rate-limit", "rl", nil, "maximum number of http requests per second of all engines, count/unit or engine=count/unit per engine (example: -rl 10, -rl shodan=2/s,fofa=30/m)", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.IntVarP(&options.RateLimitMinute, "rate-limit-minute", "rlm", 0, "maximum number of requests per minute of all engines"),
+		flagSet.IntVar(&options.Retries, "retry", 2, "number of times to retry a failed request"),
+		flagSet.StringVar(&options.Proxy, "proxy", "", "http proxy to use with uncover"),
+	)
+
+	flagSet.CreateGroup("debug", "Debug",
+		flagSet.BoolVar(&options.Silent, "silent", false, "show only errors in output"),
+		flagSet.BoolVar(&options.Verbose, "v", false, "show verbose output"),
+		flagSet.BoolVarP(&options.NoColor, "no-color", "nc", false, "disable colors in output"),
+	)
+
+	if err := flagSet.Parse(args...); err != nil {
+		gologger.Fatal().Msg(err.Error())
+	}
+
+	if options.Verbose {
+		gologger.DefaultLogger.SetMaxLevel(levels.LevelVerbose)
+	}
+	if options.NoColor {
+		gologger.DefaultLogger.SetFormatter(formatter.NewCLI(true))
+	}
+	if options.Silent {
+		gologger.DefaultLogger.SetMaxLevel(levels.LevelError)
+	}
+	showBanner()
+
+	if options.ProviderFile == sources.DefaultProviderConfigLocation {
+		createDefaultProviderConfig()
+	}
+	return options
+}
+
+// Serve runs the api server until the context is cancelled
+func Serve(ctx context.Context, options *ServeOptions) error {
+	opts := &uncover.Options{
+		Agents:   uncover.AllAgents(),
+		MaxRetry: options.Retries,
+		Timeout:  options.Timeout,
+		Proxy:    options.Proxy,
+	}
+	if options.ProviderFile != sources.DefaultProviderConfigLocation {
+		opts.ProviderFile = options.ProviderFile
+	}
+	if err := setRateLimits(opts, options.RateLimit, options.RateLimitMinute); err != nil {
+		return err
+	}
+	service, err := uncover.New(opts)
+	if err != nil {
+		return errorutil.NewWithErr(err).Msgf("could not create uncover service")
+	}
+	redactLogs(service.Session.Redactor)
+
+	apiServer, err := server.New(service, &server.Options{
+		Address:   options.Listen,
+		AuthToken: options.AuthToken,
+		MaxJobs:   options.MaxJobs,
+		JobTTL:    options.JobTTL,
+
+		MaxLimit:        options.MaxLimit,
+		MaxResults:      options.MaxJobResults,
+		MaxFinishedJobs: options.MaxFinishedJobs,
+	})
+	if err != nil {
+		return err
+	}
+	if options.AuthToken == "" {
+		gologger.Warning().Msgf("No auth token configured, api is accessible without authentication\n")
+	}
+	gologger.Info().Msgf("Listening on http://%s\n", options.Listen)
+	return apiServer.ListenAndServe(ctx)
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// printStats prints the per engine and per query statistics tables
+func printStats(w io.Writer, report *sources.StatsReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "\nENGINE\tRESULTS\tDUPLICATES\tERRORS\tREQUESTS\tFAILED\tRETRIES\tRATELIMIT WAIT\tDURATION\n")
+	for _, engine := range report.Engines {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			engine.Engine, engine.Results, engine.Duplicates, engine.Errors, engine.Requests,
+			engine.FailedRequests, engine.Retries, roundDuration(engine.RateLimitWait), roundDuration(engine.Duration))
+	}
+	_, _ = fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\t%d\t\t%d\t\t%s\n",
+		report.Results, report.Duplicates, report.Errors, report.Requests, report.Retries, roundDuration(report.Duration))
+	_ = tw.Flush()
+
+	if len(report.Queries) > 0 {
+		_, _ = fmt.Fprintf(tw, "\nENGINE\tQUERY\tRESULTS\tERRORS\tDURATION\n")
+		for _, query := range report.Queries {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n",
+				query.Engine, query.Query, query.Results, query.Errors, roundDuration(query.Duration))
+		}
+		_ = tw.Flush()
+	}
+
+	printQuotas(tw, report)
+
+	for _, engine := range report.Engines {
+		for _, message := range engine.ErrorMessages {
+			_, _ = fmt.Fprintf(w, "[%s] %s\n", engine.Engine, message)
+		}
+	}
+}
+
+// printQuotas prints the quota of engines reporting their quota usage
+func printQuotas(tw *tabwriter.Writer, report *sources.StatsReport) {
+	var header bool
+	for _, engine := range report.Engines {
+		if engine.QuotaRemaining == nil {
+			continue
+		}
+		if !header {
+			_, _ = fmt.Fprintf(tw, "\nENGINE\tQUOTA USED\tQUOTA REMAINING\n")
+			header = true
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\n", engine.Engine, engine.QuotaUsed, *engine.QuotaRemaining)
+	}
+	_ = tw.Flush()
+}
+
+// writeStatsJSON writes the statistics report to given file in JSON format
+func writeStatsJSON(location string, report *sources.StatsReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(location, data, 0644)
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
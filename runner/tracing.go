@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// tracing exports the spans of a run to an otlp endpoint and/or a json file
+type tracing struct {
+	provider *sdktrace.TracerProvider
+	file     *os.File
+}
+
+// setupTracing installs the global tracer provider exporting spans to given
+// otlp http endpoint and json file
+func setupTracing(ctx context.Context, endpoint, location string) (*tracing, error) {
+	t := &tracing{}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName("uncover"),
+			semconv.ServiceVersion(version),
+		)),
+	}
+	if endpoint != "" {
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	if location != "" {
+		file, err := os.Create(location)
+		if err != nil {
+			return nil, err
+		}
+		t.file = file
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	t.provider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(t.provider)
+	return t, nil
+}
+
+// Close flushes pending spans and closes the exporters
+func (t *tracing) Close(ctx context.Context) error {
+	err := t.provider.Shutdown(ctx)
+	if t.file != nil {
+		_ = t.file.Close()
+	}
+	return err
+}
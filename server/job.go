@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// JobStatus is the state of a search job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobCancelled JobStatus = "cancelled"
+	JobFailed    JobStatus = "failed"
+)
+
+// JobRequest is the body of a search job submission
+type JobRequest struct {
+	Queries []string `json:"queries"`
+	Engines []string `json:"engines"`
+	Limit   int      `json:"limit"`
+	Raw     bool     `json:"raw,omitempty"`
+}
+
+// JobResult is a single result of a search job as sent over the wire
+type JobResult struct {
+	sources.Result
+	Raw   json.RawMessage `json:"raw,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// NewJobResult converts a result of an agent to its wire representation
+func NewJobResult(result sources.Result, raw bool) JobResult {
+	jobResult := JobResult{Result: result}
+	if result.Error != nil {
+		jobResult.Error = result.Error.Error()
+	}
+	if raw && len(result.Raw) > 0 {
+		if json.Valid(result.Raw) {
+			jobResult.Raw = result.Raw
+		} else {
+			jobResult.Raw, _ = json.Marshal(string(result.Raw))
+		}
+	}
+	return jobResult
+}
+
+// JobInfo is the status of a search job
+type JobInfo struct {
+	ID      string     `json:"id"`
+	Status  JobStatus  `json:"status"`
+	Request JobRequest `json:"request"`
+	Results int        `json:"results"`
+	Errors  int        `json:"errors"`
+	Error   string     `json:"error,omitempty"`
+	// Stats are the request and result statistics of the job
+	Stats *sources.StatsReport `json:"stats,omitempty"`
+	// Truncated is true if the job was stopped after reaching the maximum number of results
+	Truncated  bool       `json:"truncated,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Job is a search job executed by the server
+type Job struct {
+	sync.RWMutex
+	info       JobInfo
+	results    []JobResult
+	maxResults int
+	stats      *sources.Stats
+	// updated is closed and replaced whenever results or status change
+	updated chan struct{}
+	cancel  context.CancelFunc
+}
+
+func newJob(request JobRequest, maxResults int) *Job {
+	return &Job{
+		maxResults: maxResults,
+		stats:      sources.NewStats(),
+		info: JobInfo{
+			ID:        newJobID(),
+			Status:    JobQueued,
+			Request:   request,
+			CreatedAt: time.Now(),
+		},
+		updated: make(chan struct{}),
+	}
+}
+
+func newJobID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Info returns a snapshot of the job status
+func (job *Job) Info() JobInfo {
+	job.RLock()
+	defer job.RUnlock()
+	info := job.info
+	if info.StartedAt != nil {
+		info.Stats = job.stats.Report()
+	}
+	return info
+}
+
+// Done returns true if the job is not running anymore
+func (job *Job) Done() bool {
+	job.RLock()
+	defer job.RUnlock()
+	return job.done()
+}
+
+func (job *Job) done() bool {
+	switch job.info.Status {
+	case JobCompleted, JobCancelled, JobFailed:
+		return true
+	}
+	return false
+}
+
+// Cancel stops the job if it is still queued or running
+func (job *Job) Cancel() {
+	job.Lock()
+	defer job.Unlock()
+	if job.done() {
+		return
+	}
+	if job.cancel != nil {
+		job.cancel()
+	}
+	job.finish(JobCancelled, nil)
+}
+
+func (job *Job) start(cancel context.CancelFunc) bool {
+	job.Lock()
+	defer job.Unlock()
+	if job.done() {
+		return false
+	}
+	now := time.Now()
+	job.cancel = cancel
+	job.info.Status = JobRunning
+	job.info.StartedAt = &now
+	job.notify()
+	return true
+}
+
+func (job *Job) add(result JobResult) {
+	job.Lock()
+	defer job.Unlock()
+	if job.done() {
+		return
+	}
+	if result.Error != "" {
+		job.info.Errors++
+	} else {
+		job.info.Results++
+	}
+	job.results = append(job.results, result)
+	if job.maxResults > 0 && len(job.results) >= job.maxResults {
+		// stop the search instead of keeping unbounded results in memory
+		job.info.Truncated = true
+		if job.cancel != nil {
+			job.cancel()
+		}
+		job.finish(JobCompleted, nil)
+		return
+	}
+	job.notify()
+}
+
+// complete marks the job as completed or failed unless it was cancelled
+func (job *Job) complete(err error) {
+	job.Lock()
+	defer job.Unlock()
+	if job.done() {
+		return
+	}
+	if err != nil {
+		job.finish(JobFailed, err)
+		return
+	}
+	job.finish(JobCompleted, nil)
+}
+
+func (job *Job) finish(status JobStatus, err error) {
+	now := time.Now()
+	job.info.Status = status
+	job.info.FinishedAt = &now
+	if err != nil {
+		job.info.Error = err.Error()
+	}
+	job.notify()
+}
+
+func (job *Job) notify() {
+	close(job.updated)
+	job.updated = make(chan struct{})
+}
+
+// next returns the results after given offset, whether the job
+// is finished and a channel closed on the next update
+func (job *Job) next(offset int) ([]JobResult, bool, <-chan struct{}) {
+	job.RLock()
+	defer job.RUnlock()
+	var results []JobResult
+	if offset < len(job.results) {
+		results = job.results[offset:]
+	}
+	return results, job.done(), job.updated
+}
@@ -0,0 +1,396 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+const (
+	// DefaultMaxJobs is the default number of jobs executed concurrently
+	DefaultMaxJobs = 4
+	// DefaultJobTTL is the default time finished jobs are kept in memory
+	DefaultJobTTL = time.Hour
+	// DefaultLimit is the default limit of results of a job per query and engine
+	DefaultLimit = 100
+	// DefaultMaxLimit is the default maximum limit of results of a job per query and engine
+	DefaultMaxLimit = 1000
+	// DefaultMaxResults is the default maximum number of results kept per job
+	DefaultMaxResults = 10000
+	// DefaultMaxFinishedJobs is the default number of finished jobs kept in memory
+	DefaultMaxFinishedJobs = 100
+)
+
+// Options contains the configuration of the api server
+type Options struct {
+	// Address to listen on
+	Address string
+	// AuthToken if set is required as bearer token on all api requests
+	AuthToken string
+	// MaxJobs is the maximum number of jobs executed concurrently
+	MaxJobs int
+	// JobTTL is the duration finished jobs are kept in memory
+	JobTTL time.Duration
+	// MaxLimit caps the limit of results of a job per query and engine
+	MaxLimit int
+	// MaxResults is the maximum number of results kept per job,
+	// jobs are stopped once they reach it
+	MaxResults int
+	// MaxFinishedJobs is the number of finished jobs kept in memory,
+	// the oldest finished jobs are evicted before their ttl expires
+	MaxFinishedJobs int
+}
+
+// Server exposes an uncover service over a REST api. All jobs share the
+// session and rate limits of the service it was created with and collect
+// their own statistics.
+type Server struct {
+	options *Options
+	service *uncover.Service
+	router  *httprouter.Router
+	slots   chan struct{}
+
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+// New creates a new api server for given uncover service
+func New(service *uncover.Service, options *Options) (*Server, error) {
+	if service == nil {
+		return nil, errorutil.NewWithTag("server", "uncover service cannot be nil")
+	}
+	if options.MaxJobs <= 0 {
+		options.MaxJobs = DefaultMaxJobs
+	}
+	if options.JobTTL <= 0 {
+		options.JobTTL = DefaultJobTTL
+	}
+	if options.MaxLimit <= 0 {
+		options.MaxLimit = DefaultMaxLimit
+	}
+	if options.MaxResults <= 0 {
+		options.MaxResults = DefaultMaxResults
+	}
+	if options.MaxFinishedJobs <= 0 {
+		options.MaxFinishedJobs = DefaultMaxFinishedJobs
+	}
+	server := &Server{
+		options: options,
+		service: service,
+		router:  httprouter.New(),
+		slots:   make(chan struct{}, options.MaxJobs),
+		jobs:    make(map[string]*Job),
+	}
+	server.router.GET("/healthz", server.handleHealth)
+	server.router.GET("/api/v1/engines", server.authenticated(server.handleEngines))
+	server.router.GET("/api/v1/jobs", server.authenticated(server.handleListJobs))
+	server.router.POST("/api/v1/jobs", server.authenticated(server.handleSubmitJob))
+	server.router.GET("/api/v1/jobs/:id", server.authenticated(server.handleGetJob))
+	server.router.DELETE("/api/v1/jobs/:id", server.authenticated(server.handleCancelJob))
+	server.router.GET("/api/v1/jobs/:id/results", server.authenticated(server.handleJobResults))
+	return server, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the api until the context is cancelled
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.options.Address,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go s.cleanup(ctx)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- httpServer.ListenAndServe()
+	}()
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		s.cancelAll()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+// Submit validates and starts a new search job
+func (s *Server) Submit(request JobRequest) (*Job, error) {
+	if len(request.Queries) == 0 {
+		return nil, errorutil.NewWithTag("server", "no query provided")
+	}
+	if len(request.Engines) == 0 {
+		request.Engines = []string{"shodan"}
+	}
+	allAgents := uncover.AllAgents()
+	for _, engine := range request.Engines {
+		if !slices.Contains(allAgents, engine) {
+			return nil, errorutil.NewWithTag("server", "unsupported engine %s", engine)
+		}
+	}
+	if request.Limit <= 0 {
+		request.Limit = DefaultLimit
+	}
+	request.Limit = min(request.Limit, s.options.MaxLimit)
+
+	job := newJob(request, s.options.MaxResults)
+	s.mu.Lock()
+	s.jobs[job.info.ID] = job
+	s.mu.Unlock()
+
+	go s.run(job)
+	return job, nil
+}
+
+// Job returns the job with given id
+func (s *Server) Job(id string) (*Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+func (s *Server) run(job *Job) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+	// a crash fails the job instead of the server, keys are redacted from the panic
+	defer func() {
+		if r := recover(); r != nil {
+			job.complete(s.service.Session.Redactor.Error(fmt.Errorf("job crashed: %v\n%s", r, debug.Stack())))
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if !job.start(cancel) {
+		return
+	}
+
+	request := job.Info().Request
+	service := s.service.Fork(&uncover.Options{
+		Agents:  request.Engines,
+		Queries: request.Queries,
+		Limit:   request.Limit,
+	})
+	// jobs collect their own statistics on the shared session
+	service.Stats = job.stats
+	service.Session = service.Session.WithStats(job.stats)
+	err := service.ExecuteWithCallback(ctx, func(result sources.Result) {
+		job.add(NewJobResult(result, request.Raw))
+	})
+	job.complete(err)
+	s.evict()
+}
+
+// evict removes the oldest finished jobs exceeding the maximum number of finished jobs
+func (s *Server) evict() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var finished []JobInfo
+	for _, job := range s.jobs {
+		if info := job.Info(); info.FinishedAt != nil {
+			finished = append(finished, info)
+		}
+	}
+	if len(finished) <= s.options.MaxFinishedJobs {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.Before(*finished[j].FinishedAt)
+	})
+	for _, info := range finished[:len(finished)-s.options.MaxFinishedJobs] {
+		delete(s.jobs, info.ID)
+	}
+}
+
+// cleanup removes finished jobs once their ttl expires
+func (s *Server) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			for id, job := range s.jobs {
+				info := job.Info()
+				if info.FinishedAt != nil && time.Since(*info.FinishedAt) > s.options.JobTTL {
+					delete(s.jobs, id)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Server) cancelAll() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, job := range s.jobs {
+		job.Cancel()
+	}
+}
+
+func (s *Server) authenticated(handle httprouter.Handle) httprouter.Handle {
+	if s.options.AuthToken == "" {
+		return handle
+	}
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.options.AuthToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+			return
+		}
+		handle(w, r, ps)
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleEngines(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, http.StatusOK, uncover.AllAgents())
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	s.mu.RLock()
+	jobs := make([]JobInfo, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.Info())
+	}
+	s.mu.RUnlock()
+	slices.SortFunc(jobs, func(a, b JobInfo) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var request JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid job request: %s", err))
+		return
+	}
+	job, err := s.Submit(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, job.Info())
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	job, ok := s.Job(ps.ByName("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, job.Info())
+}
+
+func (s *Server) handleCancelJob(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	job, ok := s.Job(ps.ByName("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	job.Cancel()
+	writeJSON(w, http.StatusOK, job.Info())
+}
+
+// handleJobResults streams the results of a job as NDJSON or server-sent
+// events until the job finishes or the client goes away
+func (s *Server) handleJobResults(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	job, ok := s.Job(ps.ByName("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	sse := r.URL.Query().Get("format") == "sse" || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	follow := r.URL.Query().Get("follow") != "false"
+
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	var offset int
+	for {
+		results, done, updated := job.next(offset)
+		for _, result := range results {
+			var err error
+			if sse {
+				if _, err = fmt.Fprint(w, "event: result\ndata: "); err == nil {
+					if err = encoder.Encode(result); err == nil {
+						_, err = fmt.Fprint(w, "\n")
+					}
+				}
+			} else {
+				err = encoder.Encode(result)
+			}
+			if err != nil {
+				gologger.Debug().Msgf("could not stream results of job %s: %s\n", job.info.ID, err)
+				return
+			}
+		}
+		offset += len(results)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if done || !follow {
+			if sse {
+				info := job.Info()
+				_, _ = fmt.Fprint(w, "event: done\ndata: ")
+				_ = encoder.Encode(info)
+				_, _ = fmt.Fprint(w, "\n")
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updated:
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
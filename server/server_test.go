@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover"
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+// testAgent emits given number of results and blocks until release is closed
+type testAgent struct {
+	results int
+	release chan struct{}
+}
+
+func (agent *testAgent) Name() string {
+	return "shodan"
+}
+
+func (agent *testAgent) Query(_ *sources.Session, query *sources.Query) (chan sources.Result, error) {
+	results := make(chan sources.Result)
+	go func() {
+		defer close(results)
+		for i := 0; i < agent.results && i < query.Limit; i++ {
+			results <- sources.Result{Source: agent.Name(), IP: "127.0.0.1", Port: i + 1}
+		}
+		if agent.release != nil {
+			<-agent.release
+		}
+	}()
+	return results, nil
+}
+
+func newTestServer(t *testing.T, agent sources.Agent, token string) *httptest.Server {
+	return newTestServerWithOptions(t, agent, &Options{AuthToken: token})
+}
+
+func newTestServerWithOptions(t *testing.T, agent sources.Agent, options *Options) *httptest.Server {
+	session, err := sources.NewSession(&sources.Keys{}, 0, 5, 0, []string{"shodan"}, time.Second, "")
+	require.Nil(t, err)
+	service := &uncover.Service{
+		Options:  &uncover.Options{},
+		Agents:   []sources.Agent{agent},
+		Session:  session,
+		Provider: &sources.Provider{Shodan: []string{"test"}},
+	}
+	apiServer, err := New(service, options)
+	require.Nil(t, err)
+	ts := httptest.NewServer(apiServer)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func doRequest(t *testing.T, method, url, token string, body interface{}) *http.Response {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	require.Nil(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestServerJobResults(t *testing.T) {
+	ts := newTestServer(t, &testAgent{results: 5}, "")
+
+	resp := doRequest(t, http.MethodPost, ts.URL+"/api/v1/jobs", "", JobRequest{Queries: []string{"test"}, Engines: []string{"shodan"}, Limit: 3})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var info JobInfo
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&info))
+	require.NotEmpty(t, info.ID)
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs/"+info.ID+"/results", "", nil)
+	require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	var results []JobResult
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var result JobResult
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), &result))
+		results = append(results, result)
+	}
+	require.Len(t, results, 3)
+	require.Equal(t, "127.0.0.1", results[0].IP)
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs/"+info.ID, "", nil)
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&info))
+	require.Equal(t, JobCompleted, info.Status)
+	require.Equal(t, 3, info.Results)
+	require.NotNil(t, info.Stats)
+	require.Equal(t, 3, info.Stats.Results)
+
+	// statistics are not shared with other jobs
+	resp = doRequest(t, http.MethodPost, ts.URL+"/api/v1/jobs", "", JobRequest{Queries: []string{"test"}, Engines: []string{"shodan"}, Limit: 2})
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&info))
+	require.Eventually(t, func() bool {
+		resp := doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs/"+info.ID, "", nil)
+		info = JobInfo{}
+		_ = json.NewDecoder(resp.Body).Decode(&info)
+		return info.FinishedAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 2, info.Stats.Results)
+}
+
+func TestServerCancelJob(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ts := newTestServer(t, &testAgent{results: 1, release: release}, "")
+
+	resp := doRequest(t, http.MethodPost, ts.URL+"/api/v1/jobs", "", JobRequest{Queries: []string{"test"}})
+	var info JobInfo
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&info))
+
+	resp = doRequest(t, http.MethodDelete, ts.URL+"/api/v1/jobs/"+info.ID, "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&info))
+	require.Equal(t, JobCancelled, info.Status)
+}
+
+func TestServerValidation(t *testing.T) {
+	ts := newTestServer(t, &testAgent{}, "")
+
+	resp := doRequest(t, http.MethodPost, ts.URL+"/api/v1/jobs", "", JobRequest{Engines: []string{"shodan"}})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodPost, ts.URL+"/api/v1/jobs", "", JobRequest{Queries: []string{"test"}, Engines: []string{"unknown"}})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs/unknown", "", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServerAuth(t *testing.T) {
+	ts := newTestServer(t, &testAgent{}, "secret")
+
+	resp := doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs", "", nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs", "wrong", nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs", "secret", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, ts.URL+"/healthz", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServerJobLimits(t *testing.T) {
+	ts := newTestServerWithOptions(t, &testAgent{results: 10}, &Options{MaxLimit: 5, MaxResults: 3, MaxFinishedJobs: 1})
+
+	submit := func() JobInfo {
+		resp := doRequest(t, http.MethodPost, ts.URL+"/api/v1/jobs", "", JobRequest{Queries: []string{"test"}, Limit: 100})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var info JobInfo
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&info))
+		require.Equal(t, 5, info.Request.Limit)
+		return info
+	}
+	waitDone := func(id string) JobInfo {
+		var info JobInfo
+		require.Eventually(t, func() bool {
+			resp := doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs/"+id, "", nil)
+			info = JobInfo{}
+			_ = json.NewDecoder(resp.Body).Decode(&info)
+			return info.FinishedAt != nil
+		}, 5*time.Second, 10*time.Millisecond)
+		return info
+	}
+
+	first := submit()
+	info := waitDone(first.ID)
+	require.Equal(t, JobCompleted, info.Status)
+	require.True(t, info.Truncated)
+	require.Equal(t, 3, info.Results)
+
+	second := submit()
+	waitDone(second.ID)
+	require.Eventually(t, func() bool {
+		resp := doRequest(t, http.MethodGet, ts.URL+"/api/v1/jobs/"+first.ID, "", nil)
+		return resp.StatusCode == http.StatusNotFound
+	}, 5*time.Second, 10*time.Millisecond)
+}
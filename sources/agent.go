@@ -1,8 +1,66 @@
 package sources
 
+import (
+	"strconv"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
 type Query struct {
 	Query string
 	Limit int
+	// Options contains engine specific options of the query (e.g. full=true for fofa),
+	// agents use their defaults for options which are not set
+	Options map[string]string
+}
+
+// Option returns the value of the option or defaultValue if it is not set
+func (q *Query) Option(name, defaultValue string) string {
+	if value, ok := q.Options[name]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+// BoolOption returns the boolean value of the option or defaultValue if it is not set
+func (q *Query) BoolOption(name string, defaultValue bool) (bool, error) {
+	value, ok := q.Options[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errorutil.NewWithTag("uncover", "invalid value %q of option %s, expected true or false", value, name)
+	}
+	return b, nil
+}
+
+// IntOption returns the integer value of the option or defaultValue if it is not set
+func (q *Query) IntOption(name string, defaultValue int) (int, error) {
+	value, ok := q.Options[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errorutil.NewWithTag("uncover", "invalid value %q of option %s, expected a number", value, name)
+	}
+	return i, nil
+}
+
+// PageSize returns the size of equally sized pages fetching limit results in the fewest
+// pages of at most maxSize results (e.g. 2 pages of 75 results for a limit of 150 and
+// at most 100 results per page), less than one result per page is discarded
+func PageSize(limit, maxSize int) int {
+	limit, maxSize = max(limit, 1), max(maxSize, 1)
+	pages := (limit + maxSize - 1) / maxSize
+	return (limit + pages - 1) / pages
+}
+
+// OptionAgent is implemented by agents supporting engine specific query options
+type OptionAgent interface {
+	// QueryOptions returns the names of the supported query options
+	QueryOptions() []string
 }
 
 type Agent interface {
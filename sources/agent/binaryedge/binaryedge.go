@@ -29,6 +29,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	results := make(chan sources.Result)
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 		agent.query(session, query.Query, results)
 	}()
 
@@ -0,0 +1,20 @@
+package binaryedge
+
+import (
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const SubscriptionURL = "https://api.binaryedge.io/v2/user/subscription"
+
+// ValidateKey checks the binaryedge key with the subscription endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodGet, SubscriptionURL, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-Key", session.Keys.BinaryEdgeToken)
+	_, err = session.ValidateKey(request, agent.Name())
+	return err
+}
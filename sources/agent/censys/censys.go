@@ -1,8 +1,9 @@
 package censys
 
 import (
-	"context"
 	"encoding/json"
+	"net/http"
+	"strings"
 
 	"errors"
 
@@ -22,17 +23,25 @@ func (agent *Agent) Name() string {
 	return "censys"
 }
 
+// QueryOptions returns the names of the supported query options
+func (agent *Agent) QueryOptions() []string {
+	return []string{"fields"}
+}
+
+// Query searches censys, the comma separated fields option of the query limits the
+// fields of hits returned by censys (e.g. host.ip,host.services.port), all if empty
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.CensysToken == "" || session.Keys.CensysOrgId == "" {
 		return nil, errors.New("empty censys keys")
 	}
+	fields := splitFields(query.Option("fields", ""))
 
 	// Create the Censys SDK client once
 	s := censyssdkgo.New(
 		censyssdkgo.WithOrganizationID(session.Keys.CensysOrgId),
 		censyssdkgo.WithSecurity(session.Keys.CensysToken),
 		censyssdkgo.WithClient(
-			session.Client.HTTPClient,
+			&http.Client{Transport: session.Transport(agent.Name())},
 		),
 	)
 
@@ -40,6 +49,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		var numberOfResults int
 		nextCursor := ""
@@ -48,78 +58,198 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 				Query:   query.Query,
 				PerPage: MaxPerPage,
 				Cursor:  nextCursor,
+				Fields:  fields,
 			}
-			censysResponse := agent.query(session, s, censysRequest, results)
-			if censysResponse == nil {
+			result, emitted := agent.query(session, s, censysRequest, query.Limit-numberOfResults, results)
+			if result == nil {
 				break
 			}
-			hasNextCursor := false
-			if censysResponse.ResponseEnvelopeSearchQueryResponse.Result != nil && censysResponse.ResponseEnvelopeSearchQueryResponse.Result.NextPageToken != "" {
-				hasNextCursor = true
+			if numberOfResults == 0 {
+				session.Stats.SetTotal(agent.Name(), query.Query, int(result.TotalHits))
 			}
+			numberOfResults += emitted
 
-			if !hasNextCursor || numberOfResults > query.Limit || len(censysResponse.ResponseEnvelopeSearchQueryResponse.Result.Hits) == 0 {
+			if result.NextPageToken == "" || numberOfResults >= query.Limit || len(result.Hits) == 0 {
 				break
 			}
-			nextCursor = censysResponse.ResponseEnvelopeSearchQueryResponse.Result.NextPageToken
-			numberOfResults += len(censysResponse.ResponseEnvelopeSearchQueryResponse.Result.Hits)
+			nextCursor = result.NextPageToken
 		}
 	}()
 
 	return results, nil
 }
 
-func (agent *Agent) queryURL(s *censyssdkgo.SDK, censysRequest *CensysRequest) (*operations.V3GlobaldataSearchQueryResponse, error) {
-	ctx := context.Background()
-
-	return s.GlobalData.Search(ctx, operations.V3GlobaldataSearchQueryRequest{
+func (agent *Agent) queryURL(session *sources.Session, s *censyssdkgo.SDK, censysRequest *CensysRequest) (*operations.V3GlobaldataSearchQueryResponse, error) {
+	return s.GlobalData.Search(session.Context(), operations.V3GlobaldataSearchQueryRequest{
 		SearchQueryInputBody: components.SearchQueryInputBody{
 			PageSize:  censyssdkgo.Int64(int64(censysRequest.PerPage)),
 			Query:     censysRequest.Query,
 			PageToken: &censysRequest.Cursor,
+			Fields:    censysRequest.Fields,
 		},
 	})
 }
 
-func (agent *Agent) query(session *sources.Session, s *censyssdkgo.SDK, censysRequest *CensysRequest, results chan sources.Result) *operations.V3GlobaldataSearchQueryResponse {
-	// query certificates
-	resp, err := agent.queryURL(s, censysRequest)
+// splitFields returns the non empty fields of a comma separated list
+func splitFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// query emits at most limit results of a page of hits and returns the page
+// with the number of emitted results
+func (agent *Agent) query(session *sources.Session, s *censyssdkgo.SDK, censysRequest *CensysRequest, limit int, results chan sources.Result) (*components.SearchQueryResponse, int) {
+	resp, err := agent.queryURL(session, s, censysRequest)
 	if err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: err}
-		// httputil.DrainResponseBody(resp)
-		return nil
-	}
-
-	if result := resp.ResponseEnvelopeSearchQueryResponse.Result; result != nil {
-		for _, censysResult := range result.Hits {
-
-			for _, host := range censysResult.WebpropertyV1.Resource.Endpoints {
-				result := sources.Result{Source: agent.Name()}
-				if host.IP != nil {
-					result.IP = *host.IP
-				}
-				if host.Hostname != nil {
-					result.Host = *host.Hostname
-				}
-				if host.Port != nil {
-					result.Port = *host.Port
-				}
-				if host.HTTP != nil && host.HTTP.URI != nil {
-					result.Url = *host.HTTP.URI
-				}
-				raw, _ := json.Marshal(host)
-				result.Raw = raw
-				results <- result
-			}
+		return nil, 0
+	}
+	result := resp.ResponseEnvelopeSearchQueryResponse.GetResult()
+	if result == nil {
+		return nil, 0
+	}
 
+	var emitted int
+	emit := func(result sources.Result, raw interface{}) bool {
+		if emitted >= limit {
+			return false
 		}
+		result.Raw, _ = json.Marshal(raw)
+		results <- result
+		emitted++
+		return true
 	}
+	for _, hit := range result.Hits {
+		ok := true
+		switch {
+		case hit.HostV1 != nil:
+			ok = agent.emitHost(&hit.HostV1.Resource, emit)
+		case hit.WebpropertyV1 != nil:
+			ok = agent.emitWebProperty(&hit.WebpropertyV1.Resource, emit)
+		case hit.CertificateV1 != nil:
+			ok = agent.emitCertificate(&hit.CertificateV1.Resource, emit)
+		}
+		if !ok {
+			break
+		}
+	}
+	return result, emitted
+}
 
-	return resp
+// emitHost emits a result per service of the host or the ip if it has none
+func (agent *Agent) emitHost(host *components.Host, emit func(sources.Result, interface{}) bool) bool {
+	result := sources.Result{Source: agent.Name(), IP: deref(host.IP)}
+	if as := host.AutonomousSystem; as != nil {
+		result.ASN = deref(as.Asn)
+		result.Org = deref(as.Name)
+	}
+	if location := host.Location; location != nil {
+		result.Country = deref(location.CountryCode)
+		result.City = deref(location.City)
+	}
+	if len(host.Services) == 0 {
+		return emit(result, host)
+	}
+	for _, service := range host.Services {
+		serviceResult := result
+		serviceResult.Port = deref(service.Port)
+		serviceResult.Service = deref(service.Protocol)
+		serviceResult.Software = software(service.Software)
+		serviceResult.Vulns = vulns(service.Vulns)
+		if !emit(serviceResult, service) {
+			return false
+		}
+	}
+	return true
+}
+
+// emitWebProperty emits a result per endpoint of the web property or its hostname and port
+func (agent *Agent) emitWebProperty(webProperty *components.Webproperty, emit func(sources.Result, interface{}) bool) bool {
+	result := sources.Result{
+		Source:   agent.Name(),
+		Host:     deref(webProperty.Hostname),
+		Port:     deref(webProperty.Port),
+		Software: software(webProperty.Software),
+		Vulns:    vulns(webProperty.Vulns),
+	}
+	if len(webProperty.Endpoints) == 0 {
+		return emit(result, webProperty)
+	}
+	for _, endpoint := range webProperty.Endpoints {
+		endpointResult := result
+		endpointResult.IP = deref(endpoint.IP)
+		if endpoint.Hostname != nil {
+			endpointResult.Host = *endpoint.Hostname
+		}
+		if endpoint.Port != nil {
+			endpointResult.Port = *endpoint.Port
+		}
+		if endpoint.HTTP != nil {
+			endpointResult.Service = "http"
+			endpointResult.Url = deref(endpoint.HTTP.URI)
+		}
+		if !emit(endpointResult, endpoint) {
+			return false
+		}
+	}
+	return true
+}
+
+// emitCertificate emits a result per name of the certificate
+func (agent *Agent) emitCertificate(certificate *components.Certificate, emit func(sources.Result, interface{}) bool) bool {
+	for _, name := range certificate.Names {
+		if !emit(sources.Result{Source: agent.Name(), Host: name}, certificate) {
+			return false
+		}
+	}
+	return true
+}
+
+// software returns the vendor and product names of detected software
+func software(attributes []components.Attribute) []string {
+	var names []string
+	for _, attribute := range attributes {
+		name := deref(attribute.Product)
+		if vendor := deref(attribute.Vendor); vendor != "" && name != "" {
+			name = vendor + " " + name
+		}
+		if version := deref(attribute.Version); version != "" && name != "" {
+			name += " " + version
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// vulns returns the identifiers of the vulnerabilities
+func vulns(vulns []components.Vuln) []string {
+	var ids []string
+	for _, vuln := range vulns {
+		if id := deref(vuln.ID); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return sources.NormalizeVulns(ids)
+}
+
+func deref[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
 }
 
 type CensysRequest struct {
 	Query   string
 	PerPage int
 	Cursor  string
+	Fields  []string
 }
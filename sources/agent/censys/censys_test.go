@@ -0,0 +1,144 @@
+package censys
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	censyssdkgo "github.com/censys/censys-sdk-go"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/gologger/formatter"
+	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/gologger/writer"
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFields(t *testing.T) {
+	require.Equal(t, []string{"host.ip", "host.services.port"}, splitFields(" host.ip, ,host.services.port "))
+	require.Nil(t, splitFields(""))
+}
+
+func TestQueryLimit(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"result": {"total_hits": 5, "next_page_token": "next", "hits": [
+			{"host_v1": {"resource": {"ip": "1.1.1.1", "services": [{"port": 80, "protocol": "HTTP"}, {"port": 443, "protocol": "HTTP"}, {"port": 22, "protocol": "SSH"}]}}},
+			{"host_v1": {"resource": {"ip": "2.2.2.2", "services": [{"port": 80}, {"port": 443}]}}}
+		]}}`))
+	}))
+	defer server.Close()
+
+	session, err := sources.NewSessionWithOptions(&sources.SessionOptions{Keys: &sources.Keys{CensysToken: "token", CensysOrgId: "org"}, Engines: []string{"censys"}})
+	require.Nil(t, err)
+	s := censyssdkgo.New(
+		censyssdkgo.WithServerURL(server.URL),
+		censyssdkgo.WithSecurity("token"),
+		censyssdkgo.WithOrganizationID("org"),
+		censyssdkgo.WithClient(session.Client.HTTPClient),
+	)
+
+	agent := &Agent{}
+	results := make(chan sources.Result, 10)
+	// hits are emitted once per service and never beyond the limit
+	response, emitted := agent.query(session, s, &CensysRequest{Query: "test", PerPage: MaxPerPage, Fields: []string{"host.ip"}}, 4, results)
+	close(results)
+	require.NotNil(t, response)
+	require.Equal(t, 4, emitted)
+	require.Equal(t, []interface{}{"host.ip"}, request["fields"])
+
+	var ports []int
+	for result := range results {
+		require.Nil(t, result.Error)
+		ports = append(ports, result.Port)
+	}
+	require.Equal(t, []int{80, 443, 22, 80}, ports)
+}
+
+type bufferWriter struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (w *bufferWriter) Write(data []byte, _ levels.Level) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffer.Write(data)
+	w.buffer.WriteString("\n")
+}
+
+func (w *bufferWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.String()
+}
+
+// rewriteTransport sends all requests to the test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (transport *rewriteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request.URL.Scheme = transport.target.Scheme
+	request.URL.Host = transport.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestQueryThroughSession(t *testing.T) {
+	output := &bufferWriter{}
+	gologger.DefaultLogger.SetWriter(output)
+	gologger.DefaultLogger.SetFormatter(&formatter.JSON{})
+	gologger.DefaultLogger.SetMaxLevel(levels.LevelDebug)
+	defer func() {
+		gologger.DefaultLogger.SetWriter(writer.NewCLI())
+		gologger.DefaultLogger.SetFormatter(formatter.NewCLI(false))
+		gologger.DefaultLogger.SetMaxLevel(levels.LevelInfo)
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		next := "next"
+		if request["page_token"] == next {
+			next = ""
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"result": {"total_hits": 2, "next_page_token": "` + next + `", "hits": [{"host_v1": {"resource": {"ip": "1.1.1.1"}}}]}}`))
+	}))
+	defer server.Close()
+
+	session, err := sources.NewSessionWithOptions(&sources.SessionOptions{
+		Keys:       &sources.Keys{CensysToken: "token", CensysOrgId: "org"},
+		Engines:    []string{"censys"},
+		RateLimits: map[string]sources.RateLimit{"censys": {MaxCount: 1, Duration: 200 * time.Millisecond}},
+	})
+	require.Nil(t, err)
+	target, _ := url.Parse(server.URL)
+	session.Client.HTTPClient.Transport = &rewriteTransport{target: target}
+	session.Stats = sources.NewStats()
+	session.DebugRequests = true
+
+	ch, err := (&Agent{}).Query(session, &sources.Query{Query: "test", Limit: 10})
+	require.Nil(t, err)
+	var results int
+	for result := range ch {
+		require.Nil(t, result.Error)
+		results++
+	}
+	require.Equal(t, 2, results)
+
+	// sdk requests are rate limited, counted and logged by the session
+	report := session.Stats.Report()
+	require.Len(t, report.Engines, 1)
+	require.Equal(t, 2, report.Engines[0].Requests)
+	require.Greater(t, report.Engines[0].RateLimitWait, 100*time.Millisecond)
+	require.Contains(t, output.String(), `"engine":"censys"`)
+	require.Contains(t, output.String(), `"type":"request"`)
+}
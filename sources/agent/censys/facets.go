@@ -0,0 +1,50 @@
+package censys
+
+import (
+	"errors"
+	"net/http"
+
+	censyssdkgo "github.com/censys/censys-sdk-go"
+	"github.com/censys/censys-sdk-go/models/components"
+	"github.com/censys/censys-sdk-go/models/operations"
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Facets returns the total and the most common values of the aggregation fields
+// of the query (e.g. host.services.port) with one request per field
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, fields []string) (*sources.Facets, error) {
+	if session.Keys.CensysToken == "" || session.Keys.CensysOrgId == "" {
+		return nil, errors.New("empty censys keys")
+	}
+	s := censyssdkgo.New(
+		censyssdkgo.WithOrganizationID(session.Keys.CensysOrgId),
+		censyssdkgo.WithSecurity(session.Keys.CensysToken),
+		censyssdkgo.WithClient(
+			&http.Client{Transport: session.Transport(agent.Name())},
+		),
+	)
+
+	facets := &sources.Facets{}
+	for _, field := range fields {
+		name, size := sources.ParseFacetField(field)
+		resp, err := s.GlobalData.Aggregate(session.Context(), operations.V3GlobaldataSearchAggregateRequest{
+			SearchAggregateInputBody: components.SearchAggregateInputBody{
+				Query:           query.Query,
+				Field:           name,
+				NumberOfBuckets: int64(size),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		result := resp.ResponseEnvelopeSearchAggregateResponse.GetResult()
+		if result == nil {
+			continue
+		}
+		facets.Total = int(result.TotalCount)
+		for _, bucket := range result.Buckets {
+			facets.Add(name, bucket.Key, int(bucket.Count))
+		}
+	}
+	return facets, nil
+}
@@ -0,0 +1,33 @@
+package criminalip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Count returns the total number of results of the query from its first page
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.CriminalIPToken == "" {
+		return 0, errors.New("empty criminalip keys")
+	}
+	resp, err := agent.queryURL(session, URL, &CriminalIPRequest{Query: query.Query})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	criminalipResponse := &CriminalIPResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(criminalipResponse); err != nil {
+		return 0, err
+	}
+	if criminalipResponse.Status != http.StatusOK {
+		return 0, fmt.Errorf("criminalip error status %d: %s", criminalipResponse.Status, criminalipResponse.Msg)
+	}
+	return criminalipResponse.Data.Count, nil
+}
@@ -31,6 +31,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		numberOfResults := 0
 		currentPage := 0
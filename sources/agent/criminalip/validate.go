@@ -0,0 +1,20 @@
+package criminalip
+
+import (
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const UserURL = "https://api.criminalip.io/v1/user/me"
+
+// ValidateKey checks the criminalip key with the current user endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodPost, UserURL, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("x-api-key", session.Keys.CriminalIPToken)
+	_, err = session.ValidateKey(request, agent.Name())
+	return err
+}
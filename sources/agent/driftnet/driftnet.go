@@ -55,6 +55,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		// Stable From time across all queries for this request
 		queryTime := time.Now()
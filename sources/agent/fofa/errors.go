@@ -0,0 +1,59 @@
+package fofa
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// ErrInvalidKey is returned for invalid fofa accounts or keys
+	ErrInvalidKey = errors.New("fofa: invalid account or key")
+	// ErrInvalidQuery is returned for queries with syntax errors
+	ErrInvalidQuery = errors.New("fofa: invalid query syntax")
+	// ErrNoPermission is returned for queries or fields not allowed for the account
+	ErrNoPermission = errors.New("fofa: no permission for query or fields")
+	// ErrInsufficientCredits is returned if the F points of the account are exhausted
+	ErrInsufficientCredits = errors.New("fofa: insufficient credits")
+)
+
+// errorCodes maps fofa error codes to typed errors
+var errorCodes = map[int]error{
+	-700:   ErrInvalidKey,
+	-701:   ErrInvalidKey,
+	820000: ErrInvalidQuery,
+	820001: ErrNoPermission,
+	820031: ErrInsufficientCredits,
+}
+
+// errorCodeRegex matches the code of fofa error messages (e.g. [820031] ...)
+var errorCodeRegex = regexp.MustCompile(`^\s*\[(-?\d+)\]\s*(.*)$`)
+
+// Error is an error returned by the fofa api, known codes
+// can be matched with errors.Is (e.g. ErrInsufficientCredits)
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("fofa error: %s", e.Message)
+	}
+	return fmt.Sprintf("fofa error %d: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return errorCodes[e.Code]
+}
+
+// parseError returns the error of a fofa error message
+func parseError(errmsg string) error {
+	matches := errorCodeRegex.FindStringSubmatch(errmsg)
+	if matches == nil {
+		return &Error{Message: errmsg}
+	}
+	code, _ := strconv.Atoi(matches[1])
+	return &Error{Code: code, Message: matches[2]}
+}
@@ -0,0 +1,68 @@
+package fofa
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const (
+	StatsURL = "https://fofa.info/api/v1/search/stats?key=%s&qbase64=%s&fields=%s"
+)
+
+// Facets returns the total and the most common values of the stats fields
+// of the query (e.g. port, country, title)
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, fields []string) (*sources.Facets, error) {
+	if session.Keys.FofaEmail == "" || session.Keys.FofaKey == "" {
+		return nil, errors.New("empty fofa keys")
+	}
+	names := make([]string, 0, len(fields))
+	sizes := make(map[string]int, len(fields))
+	for _, field := range fields {
+		name, size := sources.ParseFacetField(field)
+		names = append(names, name)
+		sizes[name] = size
+	}
+
+	base64Query := base64.StdEncoding.EncodeToString([]byte(query.Query))
+	statsURL := fmt.Sprintf(StatsURL, session.Keys.FofaKey, base64Query, strings.Join(names, ","))
+	request, err := sources.NewHTTPRequest(http.MethodGet, statsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	resp, err := session.Do(request, agent.Name())
+	if err != nil {
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	statsResponse := &StatsResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(statsResponse); err != nil {
+		return nil, err
+	}
+	if statsResponse.Error {
+		return nil, parseError(statsResponse.ErrMsg)
+	}
+	facets := &sources.Facets{Total: statsResponse.Size}
+	for field, buckets := range statsResponse.Aggs {
+		// stats of fofa have a fixed number of values
+		if size, ok := sizes[field]; ok && len(buckets) > size {
+			buckets = buckets[:size]
+		}
+		for _, bucket := range buckets {
+			facets.Add(field, fmt.Sprint(bucket.Name), bucket.Count)
+		}
+	}
+	return facets, nil
+}
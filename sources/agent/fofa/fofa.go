@@ -7,25 +7,24 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 
-	"github.com/projectdiscovery/gologger"
-
 	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
 )
 
 const (
-	URL = "https://fofa.info/api/v1/search/all?key=%s&qbase64=%s&fields=%s&page=%d&size=%d&full=%t"
-)
-
-var (
-	// Size is the number of results to return per page
-	Size = 100
-	// Fields is the fields to return in the results
+	URL     = "https://fofa.info/api/v1/search/all?key=%s&qbase64=%s&fields=%s&page=%d&size=%d&full=%t"
+	NextURL = "https://fofa.info/api/v1/search/next?key=%s&qbase64=%s&fields=%s&size=%d&full=%t&next=%s"
+
+	// maxPageResults is the number of results reachable with page based pagination,
+	// deeper results are only returned by the next api
+	maxPageResults = 10000
+	// DefaultSize is the default maximum number of results per page
+	DefaultSize = 100
+	// Fields are the fields returned in the results
 	Fields = "ip,port,host"
-
-	// if Full is true results from more than one year will be returned
-	Full = false
 )
 
 type Agent struct{}
@@ -34,34 +33,64 @@ func (agent *Agent) Name() string {
 	return "fofa"
 }
 
+// QueryOptions returns the names of the supported query options
+func (agent *Agent) QueryOptions() []string {
+	return []string{"full", "size", "next"}
+}
+
+// Query searches fofa with the full (results older than one year), size (maximum
+// results per page) and next (cursor pagination) options of the query
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.FofaEmail == "" || session.Keys.FofaKey == "" {
 		return nil, errors.New("empty fofa keys")
 	}
+	full, err := query.BoolOption("full", false)
+	if err != nil {
+		return nil, err
+	}
+	size, err := query.IntOption("size", DefaultSize)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, errorutil.NewWithTag("fofa", "invalid page size %d", size)
+	}
+	next, err := query.BoolOption("next", query.Limit > maxPageResults)
+	if err != nil {
+		return nil, err
+	}
 
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		var numberOfResults int
-		page := 1
+		// pages are sized from the limit to not spend credits on discarded results
+		fofaRequest := &FofaRequest{
+			Query:  query.Query,
+			Fields: Fields,
+			Size:   sources.PageSize(query.Limit, size),
+			Page:   1,
+			Full:   full,
+			Next:   next,
+		}
 		for {
-			fofaRequest := &FofaRequest{
-				Query:  query.Query,
-				Fields: Fields,
-				Size:   Size,
-				Page:   page,
-				Full:   Full,
-			}
-			fofaResponse := agent.query(URL, session, fofaRequest, results)
+			fofaResponse, emitted := agent.query(session, fofaRequest, query.Limit-numberOfResults, results)
 			if fofaResponse == nil {
 				break
 			}
-			numberOfResults += len(fofaResponse.Results)
-			page++
-			size := fofaResponse.Size
-			if size == 0 || numberOfResults >= query.Limit || len(fofaResponse.Results) == 0 || numberOfResults > size {
+			if numberOfResults == 0 {
+				session.Stats.SetTotal(agent.Name(), query.Query, fofaResponse.Size)
+			}
+			numberOfResults += emitted
+			fofaRequest.Page++
+			fofaRequest.Cursor = fofaResponse.Next
+			if fofaResponse.Size == 0 || numberOfResults >= query.Limit || len(fofaResponse.Results) == 0 || numberOfResults >= fofaResponse.Size {
+				break
+			}
+			if next && fofaResponse.Next == "" {
 				break
 			}
 		}
@@ -70,9 +99,12 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	return results, nil
 }
 
-func (agent *Agent) queryURL(session *sources.Session, URL string, fofaRequest *FofaRequest) (*http.Response, error) {
+func (agent *Agent) queryURL(session *sources.Session, fofaRequest *FofaRequest) (*http.Response, error) {
 	base64Query := base64.StdEncoding.EncodeToString([]byte(fofaRequest.Query))
-	fofaURL := fmt.Sprintf(URL, session.Keys.FofaKey, base64Query, Fields, fofaRequest.Page, fofaRequest.Size, fofaRequest.Full)
+	fofaURL := fmt.Sprintf(URL, session.Keys.FofaKey, base64Query, fofaRequest.Fields, fofaRequest.Page, fofaRequest.Size, fofaRequest.Full)
+	if fofaRequest.Next {
+		fofaURL = fmt.Sprintf(NextURL, session.Keys.FofaKey, base64Query, fofaRequest.Fields, fofaRequest.Size, fofaRequest.Full, url.QueryEscape(fofaRequest.Cursor))
+	}
 	request, err := sources.NewHTTPRequest(http.MethodGet, fofaURL, nil)
 	if err != nil {
 		return nil, err
@@ -81,32 +113,44 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, fofaRequest *
 	return session.Do(request, agent.Name())
 }
 
-func (agent *Agent) query(URL string, session *sources.Session, fofaRequest *FofaRequest, results chan sources.Result) *FofaResponse {
-	resp, err := agent.queryURL(session, URL, fofaRequest)
+// query emits at most limit results of a page and returns the page
+// with the number of emitted results
+func (agent *Agent) query(session *sources.Session, fofaRequest *FofaRequest, limit int, results chan sources.Result) (*FofaResponse, int) {
+	resp, err := agent.queryURL(session, fofaRequest)
 	if err != nil {
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
 		results <- sources.Result{Source: agent.Name(), Error: err}
-		return nil
+		return nil, 0
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		results <- sources.Result{Source: agent.Name(), Error: err}
+		return nil, 0
 	}
 	fofaResponse := &FofaResponse{}
-	RespBodyByBodyBytes, _ := io.ReadAll(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(fofaResponse); err != nil {
-		result := sources.Result{Source: agent.Name()}
-		defer func(Body io.ReadCloser) {
-			if bodyCloseErr := Body.Close(); bodyCloseErr != nil {
-				gologger.Info().Msgf("response body close error : %v", bodyCloseErr)
-			}
-		}(resp.Body)
-		raw, _ := json.Marshal(RespBodyByBodyBytes)
-		result.Raw = raw
-		results <- result
-		return nil
+	if err := json.Unmarshal(body, fofaResponse); err != nil {
+		results <- sources.Result{Source: agent.Name(), Error: errorutil.NewWithErr(err).Msgf("failed to decode fofa response: %s", sources.TruncateBody(body))}
+		return nil, 0
 	}
 	if fofaResponse.Error {
-		results <- sources.Result{Source: agent.Name(), Error: fmt.Errorf("%s", fofaResponse.ErrMsg)}
-		return nil
+		results <- sources.Result{Source: agent.Name(), Error: parseError(fofaResponse.ErrMsg)}
+		return nil, 0
 	}
 
+	var emitted int
 	for _, fofaResult := range fofaResponse.Results {
+		if emitted >= limit {
+			break
+		}
+		if len(fofaResult) < 3 {
+			continue
+		}
 		result := sources.Result{Source: agent.Name()}
 		result.IP = fofaResult[0]
 		result.Port, _ = strconv.Atoi(fofaResult[1])
@@ -114,8 +158,9 @@ func (agent *Agent) query(URL string, session *sources.Session, fofaRequest *Fof
 		raw, _ := json.Marshal(fofaResult)
 		result.Raw = raw
 		results <- result
+		emitted++
 	}
-	return fofaResponse
+	return fofaResponse, emitted
 }
 
 type FofaRequest struct {
@@ -124,4 +169,7 @@ type FofaRequest struct {
 	Page   int
 	Size   int
 	Full   bool
+	// Next uses the next api with Cursor instead of Page
+	Next   bool
+	Cursor string
 }
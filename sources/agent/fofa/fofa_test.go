@@ -0,0 +1,137 @@
+package fofa
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		errmsg   string
+		code     int
+		expected error
+	}{
+		{errmsg: "[-700] Account Invalid", code: -700, expected: ErrInvalidKey},
+		{errmsg: "[820000] query syntax error", code: 820000, expected: ErrInvalidQuery},
+		{errmsg: "[820001] no permission", code: 820001, expected: ErrNoPermission},
+		{errmsg: " [820031] F点余额不足", code: 820031, expected: ErrInsufficientCredits},
+		{errmsg: "[123] unknown", code: 123},
+		{errmsg: "unknown error"},
+	}
+	for _, test := range tests {
+		err := parseError(test.errmsg)
+		var fofaErr *Error
+		require.True(t, errors.As(err, &fofaErr), test.errmsg)
+		require.Equal(t, test.code, fofaErr.Code, test.errmsg)
+		if test.expected != nil {
+			require.ErrorIs(t, err, test.expected, test.errmsg)
+		} else {
+			require.Nil(t, errors.Unwrap(err), test.errmsg)
+		}
+	}
+}
+
+// rewriteTransport sends all requests to the test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (transport *rewriteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request.URL.Scheme = transport.target.Scheme
+	request.URL.Host = transport.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func newTestSession(t *testing.T, handler http.HandlerFunc) *sources.Session {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	session, err := sources.NewSessionWithOptions(&sources.SessionOptions{
+		Keys:       &sources.Keys{FofaEmail: "email", FofaKey: "key"},
+		Engines:    []string{"fofa"},
+		RateLimits: map[string]sources.RateLimit{"fofa": {MaxCount: 1000, Duration: time.Second}},
+	})
+	require.Nil(t, err)
+	target, _ := url.Parse(server.URL)
+	session.Client.HTTPClient.Transport = &rewriteTransport{target: target}
+	return session
+}
+
+func query(session *sources.Session, request *FofaRequest) (*FofaResponse, []sources.Result) {
+	results := make(chan sources.Result, 10)
+	response, _ := (&Agent{}).query(session, request, 10, results)
+	close(results)
+	var all []sources.Result
+	for result := range results {
+		all = append(all, result)
+	}
+	return response, all
+}
+
+func TestQuery(t *testing.T) {
+	var cursor string
+	session := newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		cursor = r.URL.Query().Get("next")
+		_, _ = w.Write([]byte(`{"error": false, "size": 2, "next": "b+c/d==", "results": [["1.1.1.1", "80", "a.example.com"], ["2.2.2.2", "443"]]}`))
+	})
+	response, results := query(session, &FofaRequest{Query: "app=test", Fields: Fields, Size: 10, Next: true, Cursor: "a+b/c=="})
+	require.NotNil(t, response)
+	require.Equal(t, "a+b/c==", cursor)
+	require.Equal(t, "b+c/d==", response.Next)
+	// results without host are skipped
+	require.Len(t, results, 1)
+	require.Equal(t, "a.example.com", results[0].Host)
+	require.Equal(t, 80, results[0].Port)
+}
+
+func TestQueryErrors(t *testing.T) {
+	session := newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"error": true, "errmsg": "[820031] insufficient credits"}`))
+	})
+	response, results := query(session, &FofaRequest{Query: "app=test", Fields: Fields, Size: 10, Page: 1})
+	require.Nil(t, response)
+	require.Len(t, results, 1)
+	require.ErrorIs(t, results[0].Error, ErrInsufficientCredits)
+
+	body := "<html>" + strings.Repeat("a", 1000) + "</html>"
+	session = newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})
+	response, results = query(session, &FofaRequest{Query: "app=test", Fields: Fields, Size: 10, Page: 1})
+	require.Nil(t, response)
+	require.Len(t, results, 1)
+	require.ErrorContains(t, results[0].Error, "failed to decode fofa response: <html>")
+	require.NotContains(t, results[0].Error.Error(), "</html>")
+}
+
+func TestQueryPageSize(t *testing.T) {
+	var pageSizes []string
+	session := newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		pageSizes = append(pageSizes, r.URL.Query().Get("size"))
+		rows := make([]string, size)
+		for i := range rows {
+			rows[i] = fmt.Sprintf(`["1.1.1.%d", "80", "host%d.example.com"]`, i, i)
+		}
+		_, _ = fmt.Fprintf(w, `{"error": false, "size": 1000, "results": [%s]}`, strings.Join(rows, ","))
+	})
+	ch, err := (&Agent{}).Query(session, &sources.Query{Query: "app=test", Limit: 150, Options: map[string]string{"full": "true"}})
+	require.Nil(t, err)
+	var results int
+	for result := range ch {
+		require.Nil(t, result.Error)
+		results++
+	}
+	require.Equal(t, 150, results)
+	// pages are sized from the limit
+	require.Equal(t, []string{"75", "75"}, pageSizes)
+}
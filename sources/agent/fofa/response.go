@@ -9,4 +9,20 @@ type FofaResponse struct {
 	Query   string     `json:"query"`
 	Results [][]string `json:"results"`
 	Size    int        `json:"size"`
+	// Next is the cursor of the next page of the next api
+	Next string `json:"next"`
+}
+
+// StatsResponse contains the fofa stats response
+type StatsResponse struct {
+	Error  bool                     `json:"error"`
+	ErrMsg string                   `json:"errmsg"`
+	Size   int                      `json:"size"`
+	Aggs   map[string][]StatsBucket `json:"aggs"`
+}
+
+// StatsBucket is the number of results with a value of a stats field
+type StatsBucket struct {
+	Count int         `json:"count"`
+	Name  interface{} `json:"name"`
 }
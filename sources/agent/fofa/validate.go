@@ -0,0 +1,36 @@
+package fofa
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const UserInfoURL = "https://fofa.info/api/v1/info/my?key=%s"
+
+// ValidateKey checks the fofa key with the user info endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodGet, fmt.Sprintf(UserInfoURL, url.QueryEscape(session.Keys.FofaKey)), nil)
+	if err != nil {
+		return err
+	}
+	body, err := session.ValidateKey(request, agent.Name())
+	if err != nil {
+		return err
+	}
+	// fofa reports invalid keys with a successful status code
+	var info struct {
+		Error  bool   `json:"error"`
+		ErrMsg string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return err
+	}
+	if info.Error {
+		return fmt.Errorf("%w: %s", sources.ErrInvalidKey, info.ErrMsg)
+	}
+	return nil
+}
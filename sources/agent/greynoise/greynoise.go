@@ -46,6 +46,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		scrollToken := ""
 		total := 0
@@ -83,6 +84,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 						IP:     item.IP,
 						Host:   h,
 						Port:   p,
+						Vulns:  sources.NormalizeVulns(item.InternetScannerIntelligence.CVEs),
 					}
 					if raw, err := json.Marshal(item); err == nil {
 						r.Raw = raw
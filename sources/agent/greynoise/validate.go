@@ -0,0 +1,20 @@
+package greynoise
+
+import (
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const PingURL = "https://api.greynoise.io/ping"
+
+// ValidateKey checks the greynoise key with the ping endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodGet, PingURL, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("key", session.Keys.GreyNoiseKey)
+	_, err = session.ValidateKey(request, agent.Name())
+	return err
+}
@@ -0,0 +1,42 @@
+package hunter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Count returns the total number of results of the query from a page with a single result
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.HunterToken == "" {
+		return 0, errors.New("empty hunter keys")
+	}
+	hunterRequest, err := newRequest(session, query)
+	if err != nil {
+		return 0, err
+	}
+	hunterRequest.PageSize = 1
+	resp, err := agent.queryURL(session, URL, hunterRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	hunterResponse := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(hunterResponse); err != nil {
+		return 0, err
+	}
+	if hunterResponse.Code != http.StatusOK {
+		return 0, fmt.Errorf("hunter error code %d: %s", hunterResponse.Code, hunterResponse.Msg)
+	}
+	if remaining, ok := parseQuota(hunterResponse.Data.RestQuota); ok {
+		used, _ := parseQuota(hunterResponse.Data.ConsumeQuota)
+		session.Stats.AddQuota(agent.Name(), used, remaining)
+	}
+	return hunterResponse.Data.Total, nil
+}
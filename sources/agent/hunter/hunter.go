@@ -6,15 +6,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
-
-	"github.com/projectdiscovery/gologger"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
 )
 
 const (
-	URL = "https://hunter.qianxin.com/openApi/search?api-key=%s&search=%s&page=%d&page_size=%d&is_web=%d&start_time=%s&end_time=%s"
+	URL = "https://hunter.qianxin.com/openApi/search?api-key=%s&search=%s&page=%d&page_size=%d&is_web=%d&status_code=%s&port_filter=%t&start_time=%s&end_time=%s"
 )
 
 var (
@@ -26,93 +30,155 @@ var (
 	EndTime    = ""
 )
 
+// quotaRegex matches the number of hunter quota messages (e.g. 今日剩余积分：499)
+var quotaRegex = regexp.MustCompile(`\d+`)
+
 type Agent struct{}
 
 func (agent *Agent) Name() string {
 	return "hunter"
 }
 
+// QueryOptions returns the names of the supported query options
+func (agent *Agent) QueryOptions() []string {
+	return []string{"is_web", "status_code", "port_filter", "start_time", "end_time"}
+}
+
+// Query searches hunter, the is_web, status_code, port_filter, start_time
+// and end_time options of the query override the package defaults
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.HunterToken == "" {
 		return nil, errors.New("empty hunter keys")
 	}
+	hunterRequest, err := newRequest(session, query)
+	if err != nil {
+		return nil, err
+	}
+	// pages of hunter have a fixed size, they are sized to not spend quota on discarded results
+	hunterRequest.PageSize = sources.PageSize(query.Limit, Size)
 
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		numberOfResults := 0
-		page := 1
 		for {
-			hunterRequest := &Request{
-				ApiKey:     session.Keys.HunterToken,
-				Search:     query.Query,
-				Page:       page,
-				PageSize:   Size,
-				StatusCode: StatusCode,
-				PortFilter: PortFilter,
-				IsWeb:      IsWeb,
-				StartTime:  StartTime,
-				EndTime:    EndTime,
-			}
-			hunterResponse := agent.query(URL, session, hunterRequest, results)
+			hunterResponse, emitted := agent.query(URL, session, hunterRequest, query.Limit-numberOfResults, results)
 			if hunterResponse == nil {
 				break
 			}
+			session.Stats.SetTotal(agent.Name(), query.Query, hunterResponse.Data.Total)
 
-			numberOfResults += len(hunterResponse.Data.Arr)
-			page++
+			numberOfResults += emitted
+			hunterRequest.Page++
 
-			if numberOfResults >= query.Limit || hunterResponse.Data.Total == 0 || len(hunterResponse.Data.Arr) == 0 {
+			if numberOfResults >= query.Limit || hunterResponse.Data.Total == 0 || len(hunterResponse.Data.Arr) == 0 || numberOfResults >= hunterResponse.Data.Total {
+				break
+			}
+			if remaining, ok := parseQuota(hunterResponse.Data.RestQuota); ok && remaining == 0 {
+				results <- sources.Result{Source: agent.Name(), Error: errorutil.NewWithTag("hunter", "quota exhausted after %d results", numberOfResults)}
 				break
 			}
-
 		}
 	}()
 
 	return results, nil
 }
 
-func (agent *Agent) query(URL string, session *sources.Session, hunterRequest *Request, results chan sources.Result) *Response {
+// newRequest returns the search request of the query with the options of the query
+func newRequest(session *sources.Session, query *sources.Query) (*Request, error) {
+	isWeb, err := query.IntOption("is_web", IsWeb)
+	if err != nil {
+		return nil, err
+	}
+	if isWeb < 0 || isWeb > 3 {
+		return nil, errorutil.NewWithTag("hunter", "invalid is_web %d, expected 1 (web), 2 (non web) or 3 (all)", isWeb)
+	}
+	portFilter, err := query.BoolOption("port_filter", PortFilter)
+	if err != nil {
+		return nil, err
+	}
+	return &Request{
+		ApiKey:     session.Keys.HunterToken,
+		Search:     query.Query,
+		Page:       1,
+		PageSize:   Size,
+		StatusCode: query.Option("status_code", StatusCode),
+		PortFilter: portFilter,
+		IsWeb:      isWeb,
+		StartTime:  query.Option("start_time", StartTime),
+		EndTime:    query.Option("end_time", EndTime),
+	}, nil
+}
+
+// query emits at most limit results of a page and returns the page
+// with the number of emitted results
+func (agent *Agent) query(URL string, session *sources.Session, hunterRequest *Request, limit int, results chan sources.Result) (*Response, int) {
 	resp, err := agent.queryURL(session, URL, hunterRequest)
 	if err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: err}
-		return nil
+		return nil, 0
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		results <- sources.Result{Source: agent.Name(), Error: err}
+		return nil, 0
+	}
 	hunterResponse := &Response{}
-	RespBodyByBodyBytes, _ := io.ReadAll(resp.Body)
-	if err := json.NewDecoder(resp.Body).Decode(hunterResponse); err != nil {
-		result := sources.Result{Source: agent.Name()}
-		defer func(Body io.ReadCloser) {
-			if bodyCloseErr := Body.Close(); bodyCloseErr != nil {
-				gologger.Info().Msgf("response body close error : %v", bodyCloseErr)
-			}
-		}(resp.Body)
-		raw, _ := json.Marshal(RespBodyByBodyBytes)
+	if err := json.Unmarshal(body, hunterResponse); err != nil {
+		results <- sources.Result{Source: agent.Name(), Error: errorutil.NewWithErr(err).Msgf("failed to decode hunter response: %s", sources.TruncateBody(body))}
+		return nil, 0
+	}
+	if hunterResponse.Code != http.StatusOK {
+		results <- sources.Result{Source: agent.Name(), Error: fmt.Errorf("hunter error code %d: %s", hunterResponse.Code, hunterResponse.Msg)}
+		return nil, 0
+	}
+	if remaining, ok := parseQuota(hunterResponse.Data.RestQuota); ok {
+		used, _ := parseQuota(hunterResponse.Data.ConsumeQuota)
+		session.Stats.AddQuota(agent.Name(), used, remaining)
+	}
+
+	var emitted int
+	for _, hunterResult := range hunterResponse.Data.Arr {
+		if emitted >= limit {
+			break
+		}
+		result := sources.Result{
+			Source:  agent.Name(),
+			IP:      hunterResult.IP,
+			Port:    hunterResult.Port,
+			Host:    hunterResult.Domain,
+			Url:     hunterResult.url(),
+			Service: hunterResult.Protocol,
+			Org:     hunterResult.Company,
+			Country: hunterResult.Country,
+			City:    hunterResult.City,
+		}
+		if result.Org == "" {
+			result.Org = hunterResult.AsOrg
+		}
+		for _, component := range hunterResult.Component {
+			result.Software = append(result.Software, strings.TrimSpace(component.Name+" "+component.Version))
+		}
+		raw, _ := json.Marshal(hunterResult)
 		result.Raw = raw
 		results <- result
-		return nil
-	}
-	if hunterResponse.Code == http.StatusOK && hunterResponse.Data.Total > 0 {
-		for _, hunterResult := range hunterResponse.Data.Arr {
-			result := sources.Result{Source: agent.Name()}
-			result.IP = hunterResult.IP
-			result.Port = hunterResult.Port
-			result.Host = hunterResult.Domain
-			raw, _ := json.Marshal(hunterResult)
-			result.Raw = raw
-			results <- result
-		}
+		emitted++
 	}
 
-	return hunterResponse
+	return hunterResponse, emitted
 }
 
 func (agent *Agent) queryURL(session *sources.Session, URL string, hunterRequest *Request) (*http.Response, error) {
 	base64Query := base64.URLEncoding.EncodeToString([]byte(hunterRequest.Search))
-	hunterURL := fmt.Sprintf(URL, hunterRequest.ApiKey, base64Query, hunterRequest.Page, hunterRequest.PageSize, hunterRequest.IsWeb, hunterRequest.StartTime, hunterRequest.EndTime)
+	hunterURL := fmt.Sprintf(URL, hunterRequest.ApiKey, base64Query, hunterRequest.Page, hunterRequest.PageSize, hunterRequest.IsWeb,
+		url.QueryEscape(hunterRequest.StatusCode), hunterRequest.PortFilter, url.QueryEscape(hunterRequest.StartTime), url.QueryEscape(hunterRequest.EndTime))
 	request, err := sources.NewHTTPRequest(http.MethodGet, hunterURL, nil)
 	if err != nil {
 		return nil, err
@@ -120,3 +186,35 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, hunterRequest
 	request.Header.Set("Accept", "application/json")
 	return session.Do(request, agent.Name())
 }
+
+// parseQuota returns the number of a hunter quota message
+func parseQuota(quota string) (int, bool) {
+	number := quotaRegex.FindString(quota)
+	if number == "" {
+		return 0, false
+	}
+	value, err := strconv.Atoi(number)
+	return value, err == nil
+}
+
+// url returns the url of web assets, built from the protocol and address if hunter omits it
+func (result *ResponseDataArr) url() string {
+	if result.URL != "" {
+		return result.URL
+	}
+	protocol := strings.ToLower(result.Protocol)
+	if protocol != "http" && protocol != "https" {
+		return ""
+	}
+	host := result.Domain
+	if host == "" {
+		host = result.IP
+	}
+	if host == "" {
+		return ""
+	}
+	if result.Port > 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(result.Port))
+	}
+	return protocol + "://" + host
+}
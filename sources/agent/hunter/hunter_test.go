@@ -0,0 +1,137 @@
+package hunter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuota(t *testing.T) {
+	tests := []struct {
+		quota    string
+		expected int
+		ok       bool
+	}{
+		{quota: "今日剩余积分：499", expected: 499, ok: true},
+		{quota: "消耗积分：10", expected: 10, ok: true},
+		{quota: "0", expected: 0, ok: true},
+		{quota: "", ok: false},
+		{quota: "unknown", ok: false},
+	}
+	for _, test := range tests {
+		value, ok := parseQuota(test.quota)
+		require.Equal(t, test.ok, ok, test.quota)
+		require.Equal(t, test.expected, value, test.quota)
+	}
+}
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		result   ResponseDataArr
+		expected string
+	}{
+		{result: ResponseDataArr{URL: "https://example.com/login", Protocol: "https", Domain: "example.com"}, expected: "https://example.com/login"},
+		{result: ResponseDataArr{Protocol: "HTTPS", Domain: "example.com", Port: 8443}, expected: "https://example.com:8443"},
+		{result: ResponseDataArr{Protocol: "http", IP: "1.1.1.1"}, expected: "http://1.1.1.1"},
+		{result: ResponseDataArr{Protocol: "http", IP: "2001:db8::1", Port: 80}, expected: "http://[2001:db8::1]:80"},
+		{result: ResponseDataArr{Protocol: "ssh", IP: "1.1.1.1", Port: 22}, expected: ""},
+		{result: ResponseDataArr{Protocol: "http"}, expected: ""},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, test.result.url())
+	}
+}
+
+// rewriteTransport sends all requests to the test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (transport *rewriteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request.URL.Scheme = transport.target.Scheme
+	request.URL.Host = transport.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func newTestSession(t *testing.T, handler http.HandlerFunc) *sources.Session {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	session, err := sources.NewSessionWithOptions(&sources.SessionOptions{
+		Keys:       &sources.Keys{HunterToken: "token"},
+		Engines:    []string{"hunter"},
+		RateLimits: map[string]sources.RateLimit{"hunter": {MaxCount: 1000, Duration: time.Second}},
+	})
+	require.Nil(t, err)
+	target, _ := url.Parse(server.URL)
+	session.Client.HTTPClient.Transport = &rewriteTransport{target: target}
+	return session
+}
+
+func collect(t *testing.T, session *sources.Session, query *sources.Query) []sources.Result {
+	ch, err := (&Agent{}).Query(session, query)
+	require.Nil(t, err)
+	var results []sources.Result
+	for result := range ch {
+		results = append(results, result)
+	}
+	return results
+}
+
+// hunterPage returns a page of size web results with the remaining quota
+func hunterPage(size, restQuota int) string {
+	results := make([]string, size)
+	for i := range results {
+		results[i] = fmt.Sprintf(`{"ip": "1.1.1.%d", "port": 443, "domain": "example.com", "protocol": "https"}`, i)
+	}
+	return fmt.Sprintf(`{"code": 200, "data": {"total": 1000, "arr": [%s], "consume_quota": "消耗积分：%d", "rest_quota": "今日剩余积分：%d"}}`, strings.Join(results, ","), size, restQuota)
+}
+
+func TestQueryPageSize(t *testing.T) {
+	mutex := &sync.Mutex{}
+	var pageSizes []int
+	session := newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		mutex.Lock()
+		pageSizes = append(pageSizes, size)
+		mutex.Unlock()
+		_, _ = w.Write([]byte(hunterPage(size, 500)))
+	})
+	session.Stats = sources.NewStats()
+	results := collect(t, session, &sources.Query{Query: "title=\"test\"", Limit: 150})
+	require.Len(t, results, 150)
+	require.Equal(t, []int{75, 75}, pageSizes)
+	require.Equal(t, "https://example.com:443", results[0].Url)
+	report := session.Stats.Report()
+	require.Len(t, report.Engines, 1)
+	require.Equal(t, 150, report.Engines[0].QuotaUsed)
+	require.Equal(t, 500, *report.Engines[0].QuotaRemaining)
+}
+
+func TestQueryQuotaExhausted(t *testing.T) {
+	var requests atomic.Int64
+	session := newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(hunterPage(10, 0)))
+	})
+	results := collect(t, session, &sources.Query{Query: "title=\"test\"", Limit: 100})
+	require.Equal(t, int64(1), requests.Load())
+	require.Len(t, results, 11)
+	require.ErrorContains(t, results[10].Error, "quota exhausted after 10 results")
+
+	session = newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code": 401, "msg": "令牌过期"}`))
+	})
+	results = collect(t, session, &sources.Query{Query: "title=\"test\"", Limit: 100})
+	require.Len(t, results, 1)
+	require.ErrorContains(t, results[0].Error, "hunter error code 401")
+}
@@ -1,9 +1,23 @@
 package hunter
 
 type ResponseDataArr struct {
-	IP     string `json:"ip"`
-	Port   int    `json:"port"`
-	Domain string `json:"domain"`
+	IP         string      `json:"ip"`
+	Port       int         `json:"port"`
+	Domain     string      `json:"domain"`
+	URL        string      `json:"url,omitempty"`
+	WebTitle   string      `json:"web_title,omitempty"`
+	StatusCode int         `json:"status_code,omitempty"`
+	Protocol   string      `json:"protocol,omitempty"`
+	Component  []Component `json:"component,omitempty"`
+	Company    string      `json:"company,omitempty"`
+	Country    string      `json:"country,omitempty"`
+	City       string      `json:"city,omitempty"`
+	AsOrg      string      `json:"as_org,omitempty"`
+}
+
+type Component struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
 }
 
 type responseData struct {
@@ -26,6 +26,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		const maxRetries = 5
 		const baseBackoff = 30 * time.Second
@@ -127,5 +128,5 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, token string)
 		return nil, err
 	}
 	request.Close = true
-	return session.Client.Do(request)
+	return session.EngineClient(agent.Name()).Do(request)
 }
@@ -0,0 +1,20 @@
+package netlas
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Count returns the total number of results of the query with the count api
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.NetlasToken == "" {
+		return 0, errors.New("empty netlas keys")
+	}
+	countResponse := &CountResponse{}
+	if err := agent.get(session, baseURL+countEndpoint+"?q="+url.QueryEscape(query.Query), countResponse); err != nil {
+		return 0, err
+	}
+	return countResponse.Count, nil
+}
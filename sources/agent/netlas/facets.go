@@ -0,0 +1,57 @@
+package netlas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const (
+	countEndpoint = "api/responses_count/"
+	facetEndpoint = "api/responses_facet/"
+)
+
+// Facets returns the total and the most common values of the facet fields
+// of the query (e.g. port, geo.country, protocol) with one request per field
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, fields []string) (*sources.Facets, error) {
+	if session.Keys.NetlasToken == "" {
+		return nil, errors.New("empty netlas keys")
+	}
+	countResponse := &CountResponse{}
+	if err := agent.get(session, baseURL+countEndpoint+"?q="+url.QueryEscape(query.Query), countResponse); err != nil {
+		return nil, err
+	}
+	facets := &sources.Facets{Total: countResponse.Count}
+	for _, field := range fields {
+		name, size := sources.ParseFacetField(field)
+		facetURL := fmt.Sprintf("%s%s?q=%s&facets=%s&size=%d", baseURL, facetEndpoint, url.QueryEscape(query.Query), url.QueryEscape(name), size)
+		facetResponse := &FacetResponse{}
+		if err := agent.get(session, facetURL, facetResponse); err != nil {
+			return nil, err
+		}
+		for _, bucket := range facetResponse.Aggregations {
+			keys := make([]string, 0, len(bucket.Key))
+			for _, key := range bucket.Key {
+				keys = append(keys, fmt.Sprint(key))
+			}
+			facets.Add(name, strings.Join(keys, ","), bucket.DocCount)
+		}
+	}
+	return facets, nil
+}
+
+// get decodes the json response of the netlas api url into v
+func (agent *Agent) get(session *sources.Session, URL string, v interface{}) error {
+	resp, err := agent.queryURL(session, URL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
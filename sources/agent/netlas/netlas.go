@@ -29,6 +29,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		numberOfResults := 0
 
@@ -72,6 +73,10 @@ func (agent *Agent) query(URL string, session *sources.Session, results chan sou
 		result.IP = netlasResult.Data.IP
 		result.Port = netlasResult.Data.Port
 		result.Host = netlasResult.Data.Host
+		for _, cve := range netlasResult.Data.CVE {
+			result.Vulns = append(result.Vulns, cve.Name)
+		}
+		result.Vulns = sources.NormalizeVulns(result.Vulns)
 		raw, _ := json.Marshal(netlasResult)
 		result.Raw = raw
 		results <- result
@@ -35,6 +35,13 @@ type Data struct {
 	Iteration   string      `json:"iteration,omitempty"`
 	HTTP        HTTP        `json:"http,omitempty"`
 	ScanDate    string      `json:"scan_date,omitempty"`
+	CVE         []CVE       `json:"cve,omitempty"`
+}
+
+type CVE struct {
+	Name      string  `json:"name,omitempty"`
+	BaseScore float64 `json:"base_score,omitempty"`
+	Severity  string  `json:"severity,omitempty"`
 }
 
 type SignatureAlgorithm struct {
@@ -254,3 +261,16 @@ type HTTP struct {
 	HTTPVersion    HTTPVersion      `json:"http_version,omitempty"`
 	StatusLine     string           `json:"status_line,omitempty"`
 }
+
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
+type FacetResponse struct {
+	Aggregations []FacetBucket `json:"aggregations"`
+}
+
+type FacetBucket struct {
+	Key      []interface{} `json:"key"`
+	DocCount int           `json:"doc_count"`
+}
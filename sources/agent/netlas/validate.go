@@ -0,0 +1,20 @@
+package netlas
+
+import (
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const userEndpoint = "api/users/current/"
+
+// ValidateKey checks the netlas key with the current user endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodGet, baseURL+userEndpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-API-Key", session.Keys.NetlasToken)
+	_, err = session.ValidateKey(request, agent.Name())
+	return err
+}
@@ -0,0 +1,42 @@
+package odin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Count returns the total number of results of the query from a page with a single result
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.OdinToken == "" {
+		return 0, errors.New("empty odin token")
+	}
+	reqBody, err := json.Marshal(&OdinRequest{Limit: 1, Query: query.Query})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %v", err)
+	}
+	httpReq, err := sources.NewHTTPRequest(http.MethodPost, OdinAPIURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("X-API-Key", session.Keys.OdinToken)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := session.Do(httpReq, agent.Name())
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var odinResp OdinResponse
+	if err := json.NewDecoder(resp.Body).Decode(&odinResp); err != nil {
+		return 0, fmt.Errorf("failed to decode odin response: %v", err)
+	}
+	return odinResp.Pagination.Total, nil
+}
@@ -35,6 +35,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		totalFetched := 0
 		var startCursor []float64
@@ -51,6 +52,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 			if odinResp == nil {
 				break
 			}
+			session.Stats.SetTotal(agent.Name(), query.Query, odinResp.Pagination.Total)
 
 			countData := len(odinResp.Data)
 			totalFetched += countData
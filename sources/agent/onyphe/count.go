@@ -0,0 +1,32 @@
+package onyphe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Count returns the total number of results of the query from its first page
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.OnypheKey == "" {
+		return 0, errors.New("empty Onyphe API key")
+	}
+	resp, err := agent.queryURL(session, &OnypheRequest{Query: query.Query, Page: 1})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var apiResponse OnypheResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return 0, err
+	}
+	if apiResponse.Error != 0 {
+		return 0, fmt.Errorf("API error code: %d", apiResponse.Error)
+	}
+	return apiResponse.Total, nil
+}
@@ -36,6 +36,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		currentPage := 1
 		totalResults := 0
@@ -51,6 +52,7 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 			if apiResponse == nil {
 				break
 			}
+			session.Stats.SetTotal(agent.Name(), query.Query, apiResponse.Total)
 
 			totalResults += len(apiResponse.Results)
 			if totalResults >= apiResponse.Total ||
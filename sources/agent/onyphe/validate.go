@@ -0,0 +1,35 @@
+package onyphe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const UserURL = "https://www.onyphe.io/api/v2/user"
+
+// ValidateKey checks the onyphe key with the user endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodGet, UserURL, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "bearer "+session.Keys.OnypheKey)
+	body, err := session.ValidateKey(request, agent.Name())
+	if err != nil {
+		return err
+	}
+	var info struct {
+		Error int    `json:"error"`
+		Text  string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return err
+	}
+	if info.Error != 0 {
+		return fmt.Errorf("%w: %s", sources.ErrInvalidKey, info.Text)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package quake
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// Count returns the total number of results of the query from a page with a single result
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.QuakeToken == "" {
+		return 0, errors.New("empty quake keys")
+	}
+	quakeRequest, err := newRequest(query)
+	if err != nil {
+		return 0, err
+	}
+	quakeRequest.Size = 1
+	quakeRequest.Include = []string{"ip"}
+	quakeRequest.Exclude = nil
+	resp, err := agent.queryURL(session, URL, quakeRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	quakeResponse := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(quakeResponse); err != nil {
+		return 0, errorutil.NewWithErr(err).Msgf("failed to decode quake response")
+	}
+	if err := parseError(quakeResponse.Code, quakeResponse.Message); err != nil {
+		return 0, err
+	}
+	return quakeResponse.Meta.Pagination.Total, nil
+}
@@ -0,0 +1,55 @@
+package quake
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidToken is returned for invalid, missing or banned quake tokens
+	ErrInvalidToken = errors.New("quake: invalid token")
+	// ErrInvalidQuery is returned for queries with syntax errors
+	ErrInvalidQuery = errors.New("quake: invalid query syntax")
+	// ErrInsufficientCredits is returned if the credits of the account are exhausted
+	ErrInsufficientCredits = errors.New("quake: insufficient credits")
+	// ErrRateLimited is returned if the api is called too frequently
+	ErrRateLimited = errors.New("quake: rate limited")
+)
+
+// errorCodes maps quake error codes to typed errors
+var errorCodes = map[string]error{
+	"u3004": ErrInsufficientCredits,
+	"u3007": ErrInvalidToken,
+	"u3009": ErrInvalidToken,
+	"u3011": ErrInvalidToken,
+	"u3015": ErrRateLimited,
+	"u3017": ErrInvalidToken,
+	"q2001": ErrInvalidQuery,
+	"q3005": ErrRateLimited,
+}
+
+// Error is an error returned by the quake api, known codes
+// can be matched with errors.Is (e.g. ErrInsufficientCredits)
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("quake error %s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return errorCodes[e.Code]
+}
+
+// parseError returns the error of a quake response code, nil for successful responses
+func parseError(code interface{}, message string) error {
+	if code == nil {
+		return nil
+	}
+	if code := fmt.Sprint(code); code != "0" {
+		return &Error{Code: code, Message: message}
+	}
+	return nil
+}
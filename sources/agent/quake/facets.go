@@ -0,0 +1,86 @@
+package quake
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+const (
+	AggregationURL = "https://quake.360.net/api/v3/aggregation/quake_service"
+)
+
+// Facets returns the most common values of the aggregation fields of the query
+// (e.g. service, port, country_cn), quake aggregations have no total
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, fields []string) (*sources.Facets, error) {
+	if session.Keys.QuakeToken == "" {
+		return nil, errors.New("empty quake keys")
+	}
+	quakeRequest, err := newRequest(query)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := query.BoolOption("latest", true)
+	if err != nil {
+		return nil, err
+	}
+	aggregationRequest := &AggregationRequest{
+		Query:     query.Query,
+		Latest:    latest,
+		StartTime: quakeRequest.StartTime,
+		EndTime:   quakeRequest.EndTime,
+	}
+	sizes := make(map[string]int, len(fields))
+	for _, field := range fields {
+		name, size := sources.ParseFacetField(field)
+		aggregationRequest.AggregationList = append(aggregationRequest.AggregationList, name)
+		aggregationRequest.Size = max(aggregationRequest.Size, size)
+		sizes[name] = size
+	}
+
+	body, err := json.Marshal(aggregationRequest)
+	if err != nil {
+		return nil, err
+	}
+	request, err := sources.NewHTTPRequest(http.MethodPost, AggregationURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-QuakeToken", session.Keys.QuakeToken)
+	resp, err := session.Do(request, agent.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	aggregationResponse := &AggregationResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(aggregationResponse); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("failed to decode quake aggregation response")
+	}
+	if err := parseError(aggregationResponse.Code, aggregationResponse.Message); err != nil {
+		return nil, err
+	}
+	if len(aggregationResponse.RawData) > 0 {
+		if err := json.Unmarshal(aggregationResponse.RawData, &aggregationResponse.Data); err != nil {
+			return nil, errorutil.NewWithErr(err).Msgf("failed to decode quake aggregation response")
+		}
+	}
+	facets := &sources.Facets{}
+	for field, buckets := range aggregationResponse.Data {
+		if size, ok := sizes[field]; ok && len(buckets) > size {
+			buckets = buckets[:size]
+		}
+		for _, bucket := range buckets {
+			facets.Add(field, fmt.Sprint(bucket.Key), bucket.DocCount)
+		}
+	}
+	return facets, nil
+}
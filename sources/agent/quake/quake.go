@@ -7,14 +7,28 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/projectdiscovery/uncover/sources"
 	errorutil "github.com/projectdiscovery/utils/errors"
 )
 
 const (
-	URL  = "https://quake.360.net/api/v3/search/quake_service"
-	Size = 100
+	URL       = "https://quake.360.net/api/v3/search/quake_service"
+	ScrollURL = "https://quake.360.net/api/v3/scroll/quake_service"
+	Size      = 100
+
+	// maxPageResults is the number of results reachable with start based pagination,
+	// deeper results are only returned by the scroll api
+	maxPageResults = 10000
+	// timeLayout is the layout of the start and end time of quake requests
+	timeLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	// Include is the fields to return in the results, all fields are returned if empty
+	Include = []string{"ip", "port", "hostname", "asn", "org", "service.name", "service.product", "service.http.host", "location.country_code", "location.city_en"}
 )
 
 type Agent struct{}
@@ -23,39 +37,70 @@ func (agent *Agent) Name() string {
 	return "quake"
 }
 
+// QueryOptions returns the names of the supported query options
+func (agent *Agent) QueryOptions() []string {
+	return []string{"scroll", "include", "exclude", "latest", "start_time", "end_time"}
+}
+
+// Query searches quake, the scroll, include, exclude, latest, start_time
+// and end_time options of the query are passed to the search
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.QuakeToken == "" {
 		return nil, errors.New("empty quake keys")
 	}
+	quakeRequest, err := newRequest(query)
+	if err != nil {
+		return nil, err
+	}
+	scroll, err := query.BoolOption("scroll", query.Limit > maxPageResults)
+	if err != nil {
+		return nil, err
+	}
+	quakeURL := URL
+	if scroll {
+		quakeURL = ScrollURL
+	}
+	// scroll pages have a fixed size, they are sized to not spend credits on discarded results
+	quakeRequest.Size = sources.PageSize(query.Limit, Size)
 
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
-		numberOfResults := 0
-
+		var numberOfResults int
 		for {
-			quakeRequest := &Request{
-				Query:       query.Query,
-				Size:        Size,
-				Start:       numberOfResults,
-				IgnoreCache: true,
-				Include:     []string{"ip", "port", "hostname"},
+			if !scroll {
+				// offset pages are not larger than the remaining limit
+				quakeRequest.Size = min(Size, max(query.Limit-numberOfResults, 1))
 			}
-			quakeResponse := agent.query(URL, session, quakeRequest, results)
+			quakeResponse, emitted := agent.query(quakeURL, session, quakeRequest, query.Limit-numberOfResults, results)
 			if quakeResponse == nil {
 				break
 			}
+			if numberOfResults == 0 {
+				session.Stats.SetTotal(agent.Name(), query.Query, quakeResponse.Meta.Pagination.Total)
+			}
+			numberOfResults += emitted
 
-			if numberOfResults > query.Limit || len(quakeResponse.Data) == 0 {
+			if numberOfResults >= query.Limit || len(quakeResponse.Data) == 0 {
 				break
 			}
-
-			numberOfResults += len(quakeResponse.Data)
-
-			// early exit without more results
-			if quakeResponse.Meta.Pagination.Count > 0 && numberOfResults >= quakeResponse.Meta.Pagination.Total {
+			// the scroll api may not report the total
+			if total := quakeResponse.Meta.Pagination.Total; total > 0 && numberOfResults >= total {
+				break
+			}
+			if scroll {
+				quakeRequest.PaginationID = quakeResponse.paginationID()
+				if quakeRequest.PaginationID == "" {
+					break
+				}
+				continue
+			}
+			quakeRequest.Start += len(quakeResponse.Data)
+			if quakeRequest.Start >= maxPageResults {
+				results <- sources.Result{Source: agent.Name(), Error: errorutil.NewWithTag("quake", "results beyond %d require the scroll option", maxPageResults)}
 				break
 			}
 		}
@@ -64,18 +109,73 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	return results, nil
 }
 
-func (agent *Agent) query(URL string, session *sources.Session, quakeRequest *Request, results chan sources.Result) *Response {
+// newRequest returns the search request of the query with the options of the query
+func newRequest(query *sources.Query) (*Request, error) {
+	latest, err := query.BoolOption("latest", false)
+	if err != nil {
+		return nil, err
+	}
+	startTime, err := parseTime(query.Option("start_time", ""))
+	if err != nil {
+		return nil, err
+	}
+	endTime, err := parseTime(query.Option("end_time", ""))
+	if err != nil {
+		return nil, err
+	}
+	return &Request{
+		Query:       query.Query,
+		IgnoreCache: true,
+		Latest:      latest,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Include:     splitFields(query.Option("include", strings.Join(Include, ","))),
+		Exclude:     splitFields(query.Option("exclude", "")),
+	}, nil
+}
+
+// parseTime returns the time in the layout of quake requests,
+// dates and times in RFC3339 or quake layout are accepted
+func parseTime(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	for _, layout := range []string{timeLayout, time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format(timeLayout), nil
+		}
+	}
+	return "", errorutil.NewWithTag("quake", "invalid time %s, expected %s", value, timeLayout)
+}
+
+// splitFields returns the non empty fields of a comma separated list
+func splitFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// query emits at most limit results of a page and returns the page
+// with the number of emitted results
+func (agent *Agent) query(URL string, session *sources.Session, quakeRequest *Request, limit int, results chan sources.Result) (*Response, int) {
 	resp, err := agent.queryURL(session, URL, quakeRequest)
 	if err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: err}
-		return nil
+		return nil, 0
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 
 	quakeResponse := &Response{}
 	respdata, err := io.ReadAll(resp.Body)
 	if err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: fmt.Errorf("%v: %v", err, string(respdata))}
-		return nil
+		return nil, 0
 	}
 	if err := json.NewDecoder(bytes.NewReader(respdata)).Decode(quakeResponse); err != nil {
 		errx := errorutil.NewWithErr(err)
@@ -84,23 +184,55 @@ func (agent *Agent) query(URL string, session *sources.Session, quakeRequest *Re
 		if err := json.NewDecoder(bytes.NewReader(respdata)).Decode(&errMap); err == nil {
 			errx = errx.Msgf("failed to decode quake response: %v", errMap)
 		} else {
-			errx = errx.Msgf("failed to decode quake response: %s", string(respdata))
+			errx = errx.Msgf("failed to decode quake response: %s", sources.TruncateBody(respdata))
 		}
 		results <- sources.Result{Source: agent.Name(), Error: errx}
-		return nil
+		return nil, 0
+	}
+	if err := parseError(quakeResponse.Code, quakeResponse.Message); err != nil {
+		results <- sources.Result{Source: agent.Name(), Error: err}
+		return nil, 0
+	}
+	if len(quakeResponse.RawData) > 0 {
+		if err := json.Unmarshal(quakeResponse.RawData, &quakeResponse.Data); err != nil {
+			results <- sources.Result{Source: agent.Name(), Error: errorutil.NewWithErr(err).Msgf("failed to decode quake response: %s", sources.TruncateBody(respdata))}
+			return nil, 0
+		}
 	}
 
-	for _, quakeResult := range quakeResponse.Data {
-		result := sources.Result{Source: agent.Name()}
-		result.IP = quakeResult.IP
-		result.Port = quakeResult.Port
-		result.Host = quakeResult.Hostname
-		raw, _ := json.Marshal(quakeResult)
-		result.Raw = raw
+	var emitted int
+	for _, raw := range quakeResponse.Data {
+		if emitted >= limit {
+			break
+		}
+		quakeResult := responseData{}
+		if err := json.Unmarshal(raw, &quakeResult); err != nil {
+			results <- sources.Result{Source: agent.Name(), Error: err}
+			continue
+		}
+		result := sources.Result{
+			Source:  agent.Name(),
+			IP:      quakeResult.IP,
+			Port:    quakeResult.Port,
+			Host:    quakeResult.Hostname,
+			Service: quakeResult.Service.Name,
+			ASN:     quakeResult.ASN,
+			Org:     quakeResult.Org,
+			Country: quakeResult.Location.CountryCode,
+			City:    quakeResult.Location.CityEn,
+			Raw:     raw,
+		}
+		if result.Host == "" {
+			result.Host = quakeResult.Service.HTTP.Host
+		}
+		if quakeResult.Service.Product != "" {
+			result.Software = []string{quakeResult.Service.Product}
+		}
 		results <- result
+		emitted++
 	}
 
-	return quakeResponse
+	return quakeResponse, emitted
 }
 
 func (agent *Agent) queryURL(session *sources.Session, URL string, quakeRequest *Request) (*http.Response, error) {
@@ -122,3 +254,11 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, quakeRequest
 	request.Header.Set("X-QuakeToken", session.Keys.QuakeToken)
 	return session.Do(request, agent.Name())
 }
+
+// paginationID returns the scroll cursor of the next page
+func (response *Response) paginationID() string {
+	if response.Meta.PaginationID != "" {
+		return response.Meta.PaginationID
+	}
+	return response.Meta.Pagination.PaginationID
+}
@@ -0,0 +1,170 @@
+package quake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		code     interface{}
+		expected error
+	}{
+		{code: "u3004", expected: ErrInsufficientCredits},
+		{code: "u3007", expected: ErrInvalidToken},
+		{code: "q2001", expected: ErrInvalidQuery},
+		{code: "q3005", expected: ErrRateLimited},
+		{code: "u9999"},
+		{code: float64(1)},
+	}
+	for _, test := range tests {
+		err := parseError(test.code, "message")
+		var quakeErr *Error
+		require.True(t, errors.As(err, &quakeErr), test.code)
+		require.Equal(t, fmt.Sprint(test.code), quakeErr.Code)
+		if test.expected != nil {
+			require.ErrorIs(t, err, test.expected, test.code)
+		} else {
+			require.Nil(t, errors.Unwrap(err), test.code)
+		}
+	}
+	require.Nil(t, parseError(nil, ""))
+	require.Nil(t, parseError(float64(0), "Successful."))
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		err      bool
+	}{
+		{value: "", expected: ""},
+		{value: "2024-01-02", expected: "2024-01-02 00:00:00"},
+		{value: "2024-01-02 15:04:05", expected: "2024-01-02 15:04:05"},
+		{value: "2024-01-02T15:04:05+02:00", expected: "2024-01-02 13:04:05"},
+		{value: "yesterday", err: true},
+	}
+	for _, test := range tests {
+		value, err := parseTime(test.value)
+		if test.err {
+			require.ErrorContains(t, err, "invalid time", test.value)
+			continue
+		}
+		require.Nil(t, err, test.value)
+		require.Equal(t, test.expected, value, test.value)
+	}
+}
+
+func TestPaginationID(t *testing.T) {
+	response := &Response{}
+	require.Empty(t, response.paginationID())
+	response.Meta.Pagination.PaginationID = "page"
+	require.Equal(t, "page", response.paginationID())
+	response.Meta.PaginationID = "scroll"
+	require.Equal(t, "scroll", response.paginationID())
+}
+
+// rewriteTransport sends all requests to the test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (transport *rewriteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request.URL.Scheme = transport.target.Scheme
+	request.URL.Host = transport.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func newTestSession(t *testing.T, handler http.HandlerFunc) *sources.Session {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	session, err := sources.NewSessionWithOptions(&sources.SessionOptions{
+		Keys:       &sources.Keys{QuakeToken: "token"},
+		Engines:    []string{"quake"},
+		RateLimits: map[string]sources.RateLimit{"quake": {MaxCount: 1000, Duration: time.Second}},
+	})
+	require.Nil(t, err)
+	target, _ := url.Parse(server.URL)
+	session.Client.HTTPClient.Transport = &rewriteTransport{target: target}
+	return session
+}
+
+func collect(t *testing.T, session *sources.Session, query *sources.Query) []sources.Result {
+	ch, err := (&Agent{}).Query(session, query)
+	require.Nil(t, err)
+	var results []sources.Result
+	for result := range ch {
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestQueryPageSize(t *testing.T) {
+	mutex := &sync.Mutex{}
+	var sizes, starts []int
+	var paginationIDs []string
+	session := newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		request := &Request{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		mutex.Lock()
+		sizes = append(sizes, request.Size)
+		starts = append(starts, request.Start)
+		paginationIDs = append(paginationIDs, request.PaginationID)
+		mutex.Unlock()
+		data := make([]string, request.Size)
+		for i := range data {
+			data[i] = fmt.Sprintf(`{"ip": "1.1.1.%d", "port": 80}`, i)
+		}
+		_, _ = fmt.Fprintf(w, `{"code": 0, "data": [%s], "meta": {"pagination_id": "next", "pagination": {"total": 1000}}}`, strings.Join(data, ","))
+	})
+
+	// offset pages are not larger than the remaining limit
+	results := collect(t, session, &sources.Query{Query: "port:80", Limit: 150})
+	require.Len(t, results, 150)
+	require.Equal(t, []int{100, 50}, sizes)
+	require.Equal(t, []int{0, 100}, starts)
+
+	// scroll pages have a fixed size
+	sizes, starts, paginationIDs = nil, nil, nil
+	results = collect(t, session, &sources.Query{Query: "port:80", Limit: 150, Options: map[string]string{"scroll": "true"}})
+	require.Len(t, results, 150)
+	require.Equal(t, []int{75, 75}, sizes)
+	require.Equal(t, []string{"", "next"}, paginationIDs)
+}
+
+func TestQueryErrors(t *testing.T) {
+	session := newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code": "u3004", "message": "insufficient credits", "data": []}`))
+	})
+	results := collect(t, session, &sources.Query{Query: "port:80", Limit: 10})
+	require.Len(t, results, 1)
+	require.ErrorIs(t, results[0].Error, ErrInsufficientCredits)
+
+	// error codes are reported whatever the type of data
+	session = newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code": "u3004", "message": "insufficient credits", "data": {}}`))
+	})
+	results = collect(t, session, &sources.Query{Query: "port:80", Limit: 10})
+	require.Len(t, results, 1)
+	require.ErrorIs(t, results[0].Error, ErrInsufficientCredits)
+
+	body := "<html>" + strings.Repeat("a", 1000) + "</html>"
+	session = newTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})
+	results = collect(t, session, &sources.Query{Query: "port:80", Limit: 10})
+	require.Len(t, results, 1)
+	require.ErrorContains(t, results[0].Error, "failed to decode quake response: <html>")
+	require.NotContains(t, results[0].Error.Error(), "</html>")
+}
@@ -3,7 +3,22 @@ package quake
 type Request struct {
 	Query       string   `json:"query"`
 	Size        int      `json:"size"`
-	Start       int      `json:"start"`
+	Start       int      `json:"start,omitempty"`
 	IgnoreCache bool     `json:"ignore_cache"`
-	Include     []string `json:"include"`
+	Latest      bool     `json:"latest,omitempty"`
+	StartTime   string   `json:"start_time,omitempty"`
+	EndTime     string   `json:"end_time,omitempty"`
+	Include     []string `json:"include,omitempty"`
+	Exclude     []string `json:"exclude,omitempty"`
+	// PaginationID is the cursor of the scroll api
+	PaginationID string `json:"pagination_id,omitempty"`
+}
+
+type AggregationRequest struct {
+	Query           string   `json:"query"`
+	AggregationList []string `json:"aggregation_list"`
+	Size            int      `json:"size"`
+	Latest          bool     `json:"latest"`
+	StartTime       string   `json:"start_time,omitempty"`
+	EndTime         string   `json:"end_time,omitempty"`
 }
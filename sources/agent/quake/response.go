@@ -1,9 +1,28 @@
 package quake
 
+import "encoding/json"
+
 type responseData struct {
-	Hostname string `json:"hostname"`
-	IP       string `json:"ip"`
-	Port     int    `json:"port"`
+	Hostname string          `json:"hostname"`
+	IP       string          `json:"ip"`
+	Port     int             `json:"port"`
+	ASN      int             `json:"asn"`
+	Org      string          `json:"org"`
+	Service  responseService `json:"service"`
+	Location location        `json:"location"`
+}
+
+type responseService struct {
+	Name    string `json:"name"`
+	Product string `json:"product"`
+	HTTP    struct {
+		Host string `json:"host"`
+	} `json:"http"`
+}
+
+type location struct {
+	CountryCode string `json:"country_code"`
+	CityEn      string `json:"city_en"`
 }
 
 type pagination struct {
@@ -11,14 +30,35 @@ type pagination struct {
 	PageIndex int `json:"page_index"`
 	PageSize  int `json:"page_size"`
 	Total     int `json:"total"`
+	// PaginationID is the cursor of the next page of the scroll api
+	PaginationID string `json:"pagination_id"`
 }
 
 type meta struct {
 	Pagination pagination `json:"pagination"`
+	// PaginationID is returned at the top of meta by the scroll api
+	PaginationID string `json:"pagination_id"`
 }
 
 type Response struct {
-	Data    []responseData `json:"data"`
-	Message string         `json:"message"`
-	Meta    meta           `json:"meta"`
+	Code interface{} `json:"code"`
+	// RawData is decoded into Data only on success as errors may have an object data
+	RawData json.RawMessage `json:"data"`
+	// Data is kept raw to preserve the included fields
+	Data    []json.RawMessage `json:"-"`
+	Message string            `json:"message"`
+	Meta    meta              `json:"meta"`
+}
+
+type AggregationResponse struct {
+	Code    interface{} `json:"code"`
+	Message string      `json:"message"`
+	// RawData is decoded into Data only on success as errors may have a list data
+	RawData json.RawMessage                `json:"data"`
+	Data    map[string][]AggregationBucket `json:"-"`
+}
+
+type AggregationBucket struct {
+	Key      interface{} `json:"key"`
+	DocCount int         `json:"doc_count"`
 }
@@ -0,0 +1,36 @@
+package quake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const UserInfoURL = "https://quake.360.net/api/v3/user/info"
+
+// ValidateKey checks the quake token with the user info endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodGet, UserInfoURL, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-QuakeToken", session.Keys.QuakeToken)
+	body, err := session.ValidateKey(request, agent.Name())
+	if err != nil {
+		return err
+	}
+	// quake reports invalid tokens with a non zero code
+	var info struct {
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return err
+	}
+	if code := string(info.Code); code != "0" && code != `"0"` {
+		return fmt.Errorf("%w: %s", sources.ErrInvalidKey, info.Message)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package shodan
+
+import (
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Count returns the total number of results of the query with the count api
+// which does not consume query credits
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	facets, err := agent.Facets(session, query, nil)
+	if err != nil {
+		return 0, err
+	}
+	return facets.Total, nil
+}
@@ -4,3 +4,22 @@ type ShodanResponse struct {
 	Total   int                      `json:"total"`
 	Results []map[string]interface{} `json:"matches"`
 }
+
+// HostResponse is the response of the host lookup api
+type HostResponse struct {
+	IP        string                   `json:"ip_str"`
+	Hostnames []string                 `json:"hostnames"`
+	Data      []map[string]interface{} `json:"data"`
+}
+
+// CountResponse is the response of the host count api
+type CountResponse struct {
+	Total  int                      `json:"total"`
+	Facets map[string][]FacetBucket `json:"facets"`
+}
+
+// FacetBucket is the count of a facet value
+type FacetBucket struct {
+	Count int         `json:"count"`
+	Value interface{} `json:"value"`
+}
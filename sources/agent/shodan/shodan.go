@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"strings"
 
 	"errors"
 
@@ -12,7 +14,13 @@ import (
 )
 
 const (
-	URL = "https://api.shodan.io/shodan/host/search?key=%s&query=%s&page=%d"
+	URL      = "https://api.shodan.io/shodan/host/search?key=%s&query=%s&page=%d"
+	HostURL  = "https://api.shodan.io/shodan/host/%s?key=%s&history=true"
+	CountURL = "https://api.shodan.io/shodan/host/count?key=%s&query=%s&facets=%s"
+
+	// maxHostLookups is the size of the largest cidr whose addresses are looked up
+	// one by one, larger cidrs are searched with the net filter
+	maxHostLookups = 256
 )
 
 type Agent struct{}
@@ -27,29 +35,46 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 	}
 	results := make(chan sources.Result)
 
+	if addresses := lookupAddresses(query.Query); len(addresses) > 0 {
+		go func() {
+			defer close(results)
+			defer session.Recover(agent.Name(), results)
+			agent.lookup(session, addresses, query.Limit, results)
+		}()
+		return results, nil
+	}
+
+	searchQuery := query.Query
+	if prefix, err := netip.ParsePrefix(strings.TrimSpace(searchQuery)); err == nil {
+		searchQuery = "net:" + prefix.Masked().String()
+	}
+
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		currentPage := 1
-		var numberOfResults, totalResults int
+		var numberOfResults, numberOfBanners, totalResults int
 		for {
 			shodanRequest := &ShodanRequest{
-				Query: query.Query,
+				Query: searchQuery,
 				Page:  currentPage,
 			}
 
-			shodanResponse := agent.query(URL, session, shodanRequest, results)
+			shodanResponse, emitted := agent.query(URL, session, shodanRequest, query.Limit-numberOfResults, results)
 			if shodanResponse == nil {
 				break
 			}
 			currentPage++
-			numberOfResults += len(shodanResponse.Results)
+			numberOfResults += emitted
+			numberOfBanners += len(shodanResponse.Results)
 			if totalResults == 0 {
 				totalResults = shodanResponse.Total
+				session.Stats.SetTotal(agent.Name(), query.Query, totalResults)
 			}
 
 			// query certificates
-			if numberOfResults > query.Limit || numberOfResults > totalResults || len(shodanResponse.Results) == 0 {
+			if numberOfResults >= query.Limit || numberOfBanners >= totalResults || len(shodanResponse.Results) == 0 {
 				break
 			}
 		}
@@ -67,47 +92,173 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, shodanRequest
 	return session.Do(request, agent.Name())
 }
 
-func (agent *Agent) query(URL string, session *sources.Session, shodanRequest *ShodanRequest, results chan sources.Result) *ShodanResponse {
-	// query certificates
+// query emits at most limit results of a page of banners and returns the page
+// with the number of emitted results
+func (agent *Agent) query(URL string, session *sources.Session, shodanRequest *ShodanRequest, limit int, results chan sources.Result) (*ShodanResponse, int) {
 	resp, err := agent.queryURL(session, URL, shodanRequest)
 	if err != nil {
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
 		results <- sources.Result{Source: agent.Name(), Error: err}
-		return nil
+		return nil, 0
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 
 	shodanResponse := &ShodanResponse{}
 	if err := json.NewDecoder(resp.Body).Decode(shodanResponse); err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: err}
-		return nil
+		return nil, 0
 	}
 
+	var emitted int
 	for _, shodanResult := range shodanResponse.Results {
-		result := sources.Result{Source: agent.Name()}
-		if port, ok := shodanResult["port"]; ok {
-			result.Port = int(port.(float64))
+		if emitted >= limit {
+			break
 		}
-		if ip, ok := shodanResult["ip_str"]; ok {
-			result.IP = ip.(string)
+		emitted += agent.emit(shodanResult, nil, limit-emitted, results)
+	}
+
+	return shodanResponse, emitted
+}
+
+// emit sends at most limit results, one per hostname of the shodan banner or a single
+// ip result if it has none, hostnames are used for banners without hostnames
+func (agent *Agent) emit(shodanResult map[string]interface{}, hostnames []string, limit int, results chan sources.Result) int {
+	if limit <= 0 {
+		return 0
+	}
+	result := sources.Result{Source: agent.Name()}
+	if port, ok := shodanResult["port"].(float64); ok {
+		result.Port = int(port)
+	}
+	if ip, ok := shodanResult["ip_str"].(string); ok {
+		result.IP = ip
+	}
+	if bannerHostnames, ok := shodanResult["hostnames"].([]interface{}); ok && len(bannerHostnames) > 0 {
+		hostnames = nil
+		for _, hostname := range bannerHostnames {
+			hostnames = append(hostnames, fmt.Sprint(hostname))
+		}
+	}
+	if vulns, ok := shodanResult["vulns"].(map[string]interface{}); ok {
+		for vuln := range vulns {
+			result.Vulns = append(result.Vulns, vuln)
+		}
+		result.Vulns = sources.NormalizeVulns(result.Vulns)
+	}
+	result.Raw, _ = json.Marshal(shodanResult)
+
+	if len(hostnames) == 0 {
+		// only ip
+		results <- result
+		return 1
+	}
+	if len(hostnames) > limit {
+		hostnames = hostnames[:limit]
+	}
+	for _, hostname := range hostnames {
+		result.Host = hostname
+		results <- result
+	}
+	return len(hostnames)
+}
+
+// lookup emits the banners of the full service history of the addresses
+func (agent *Agent) lookup(session *sources.Session, addresses []string, limit int, results chan sources.Result) {
+	var numberOfResults int
+	for _, address := range addresses {
+		request, err := sources.NewHTTPRequest(http.MethodGet, fmt.Sprintf(HostURL, address, session.Keys.Shodan), nil)
+		if err != nil {
+			results <- sources.Result{Source: agent.Name(), Error: err}
+			return
 		}
-		// has hostnames?
-		if hostnames, ok := shodanResult["hostnames"]; ok {
-			if _, ok := hostnames.([]interface{}); ok {
-				for _, hostname := range hostnames.([]interface{}) {
-					result.Host = fmt.Sprint(hostname)
-				}
+		resp, err := session.Do(request, agent.Name())
+		if err != nil {
+			var statusErr *sources.StatusCodeError
+			if resp != nil {
+				_ = resp.Body.Close()
 			}
-			raw, _ := json.Marshal(shodanResult)
-			result.Raw = raw
-			results <- result
-		} else {
-			raw, _ := json.Marshal(shodanResult)
-			result.Raw = raw
-			// only ip
-			results <- result
+			if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+				// no information available for the address
+				continue
+			}
+			results <- sources.Result{Source: agent.Name(), Error: err}
+			continue
+		}
+
+		hostResponse := &HostResponse{}
+		err = json.NewDecoder(resp.Body).Decode(hostResponse)
+		_ = resp.Body.Close()
+		if err != nil {
+			results <- sources.Result{Source: agent.Name(), Error: err}
+			continue
 		}
+		for _, banner := range hostResponse.Data {
+			if _, ok := banner["ip_str"]; !ok {
+				banner["ip_str"] = hostResponse.IP
+			}
+			numberOfResults += agent.emit(banner, hostResponse.Hostnames, limit-numberOfResults, results)
+			if numberOfResults >= limit {
+				return
+			}
+		}
+	}
+}
+
+// lookupAddresses returns the addresses to look up if the query is an ip
+// or a cidr of at most maxHostLookups addresses
+func lookupAddresses(query string) []string {
+	query = strings.TrimSpace(query)
+	if addr, err := netip.ParseAddr(query); err == nil {
+		return []string{addr.String()}
+	}
+	prefix, err := netip.ParsePrefix(query)
+	if err != nil {
+		return nil
+	}
+	if hostBits := prefix.Addr().BitLen() - prefix.Bits(); hostBits > 30 || 1<<hostBits > maxHostLookups {
+		return nil
+	}
+	var addresses []string
+	for addr := prefix.Masked().Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		addresses = append(addresses, addr.String())
+	}
+	return addresses
+}
+
+// Facets returns the total and the most common values of the facet fields
+// of the query (e.g. port, org, country:20) without consuming query credits
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, fields []string) (*sources.Facets, error) {
+	if session.Keys.Shodan == "" {
+		return nil, errors.New("empty shodan keys")
+	}
+	countURL := fmt.Sprintf(CountURL, session.Keys.Shodan, url.QueryEscape(query.Query), url.QueryEscape(strings.Join(fields, ",")))
+	request, err := sources.NewHTTPRequest(http.MethodGet, countURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := session.Do(request, agent.Name())
+	if err != nil {
+		return nil, err
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 
-	return shodanResponse
+	countResponse := &CountResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(countResponse); err != nil {
+		return nil, err
+	}
+	facets := &sources.Facets{Total: countResponse.Total}
+	for field, buckets := range countResponse.Facets {
+		for _, bucket := range buckets {
+			facets.Add(field, fmt.Sprint(bucket.Value), bucket.Count)
+		}
+	}
+	return facets, nil
 }
 
 type ShodanRequest struct {
@@ -0,0 +1,105 @@
+package shodan
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupAddresses(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{query: " 1.1.1.1 ", expected: 1},
+		{query: "2001:db8::1", expected: 1},
+		{query: "1.1.1.0/30", expected: 4},
+		{query: "1.1.1.7/24", expected: 256},
+		{query: "1.1.0.0/23", expected: 0},
+		{query: "2001:db8::/120", expected: 256},
+		{query: "2001:db8::/64", expected: 0},
+		{query: "ssl:example.com", expected: 0},
+	}
+	for _, test := range tests {
+		require.Len(t, lookupAddresses(test.query), test.expected, test.query)
+	}
+	require.Equal(t, []string{"1.1.1.0", "1.1.1.1", "1.1.1.2", "1.1.1.3"}, lookupAddresses("1.1.1.2/30"))
+}
+
+func TestEmit(t *testing.T) {
+	agent := &Agent{}
+	results := make(chan sources.Result, 10)
+
+	banner := map[string]interface{}{"ip_str": "1.1.1.1", "port": float64(443), "hostnames": []interface{}{"a.example.com", "b.example.com"}}
+	require.Equal(t, 2, agent.emit(banner, []string{"ignored.example.com"}, 10, results))
+	require.Equal(t, "a.example.com", (<-results).Host)
+	require.Equal(t, "b.example.com", (<-results).Host)
+
+	// hostnames of the host are used for banners without hostnames
+	banner = map[string]interface{}{"ip_str": "1.1.1.1", "port": float64(80)}
+	require.Equal(t, 1, agent.emit(banner, []string{"host.example.com"}, 10, results))
+	result := <-results
+	require.Equal(t, "host.example.com", result.Host)
+	require.Equal(t, 80, result.Port)
+
+	// banners without any hostname are emitted once
+	require.Equal(t, 1, agent.emit(banner, nil, 10, results))
+	result = <-results
+	require.Equal(t, "1.1.1.1", result.IP)
+	require.Empty(t, result.Host)
+
+	// hostnames beyond the limit are not emitted
+	require.Equal(t, 1, agent.emit(banner, []string{"a.example.com", "b.example.com"}, 1, results))
+	require.Equal(t, "a.example.com", (<-results).Host)
+	require.Equal(t, 0, agent.emit(banner, nil, 0, results))
+	require.Empty(t, results)
+}
+
+// rewriteTransport sends all requests to the test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (transport *rewriteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request.URL.Scheme = transport.target.Scheme
+	request.URL.Host = transport.target.Host
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestQueryLimit(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"total": 100, "matches": [
+			{"ip_str": "1.1.1.1", "port": 443, "hostnames": ["a.example.com", "b.example.com"]},
+			{"ip_str": "2.2.2.2", "port": 443, "hostnames": ["c.example.com", "d.example.com"]}
+		]}`))
+	}))
+	defer server.Close()
+
+	session, err := sources.NewSessionWithOptions(&sources.SessionOptions{
+		Keys:       &sources.Keys{Shodan: "key"},
+		Engines:    []string{"shodan"},
+		RateLimits: map[string]sources.RateLimit{"shodan": {MaxCount: 1000, Duration: time.Second}},
+	})
+	require.Nil(t, err)
+	target, _ := url.Parse(server.URL)
+	session.Client.HTTPClient.Transport = &rewriteTransport{target: target}
+
+	// results are counted per hostname and the search stops at the limit
+	ch, err := (&Agent{}).Query(session, &sources.Query{Query: "ssl:example.com", Limit: 3})
+	require.Nil(t, err)
+	var hosts []string
+	for result := range ch {
+		require.Nil(t, result.Error)
+		hosts = append(hosts, result.Host)
+	}
+	require.Equal(t, []string{"a.example.com", "b.example.com", "c.example.com"}, hosts)
+	require.Equal(t, int64(1), requests.Load())
+}
@@ -0,0 +1,21 @@
+package shodan
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const APIInfoURL = "https://api.shodan.io/api-info?key=%s"
+
+// ValidateKey checks the shodan key with the api-info endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodGet, fmt.Sprintf(APIInfoURL, url.QueryEscape(session.Keys.Shodan)), nil)
+	if err != nil {
+		return err
+	}
+	_, err = session.ValidateKey(request, agent.Name())
+	return err
+}
@@ -1,20 +1,32 @@
 package shodanidb
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"errors"
 
-	"github.com/projectdiscovery/mapcidr"
 	"github.com/projectdiscovery/uncover/sources"
-	iputil "github.com/projectdiscovery/utils/ip"
+	errorutil "github.com/projectdiscovery/utils/errors"
 )
 
 const (
 	URL = "https://internetdb.shodan.io/%s"
+
+	// maxIPv6HostBits is the number of host bits of the largest ipv6 cidr
+	// which can be swept, larger ranges are mostly unallocated
+	maxIPv6HostBits = 16
+
+	// DefaultConcurrency is the number of addresses of a cidr looked up in parallel,
+	// requests are still bound to the rate limit of the engine
+	DefaultConcurrency = 10
 )
 
 type Agent struct{}
@@ -23,23 +35,105 @@ func (agent *Agent) Name() string {
 	return "shodan-idb"
 }
 
-func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
-	results := make(chan sources.Result)
+// QueryOptions returns the names of the supported query options
+func (agent *Agent) QueryOptions() []string {
+	return []string{"concurrency"}
+}
 
-	if !iputil.IsIP(query.Query) && !iputil.IsCIDR(query.Query) {
-		return nil, errors.New("only ip/cidr are accepted")
+// Query looks up the addresses of the ip or cidr query, the concurrency option of
+// the query sets the number of addresses looked up in parallel
+func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
+	prefix, err := parsePrefix(query.Query)
+	if err != nil {
+		return nil, err
+	}
+	concurrency, err := query.IntOption("concurrency", DefaultConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency <= 0 {
+		return nil, errorutil.NewWithTag("shodan-idb", "concurrency must be positive")
 	}
 
+	results := make(chan sources.Result)
+
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
-		shodanRequest := &ShodanRequest{Query: query.Query}
-		agent.query(URL, session, shodanRequest, results)
+		agent.sweep(URL, session, prefix, query.Limit, concurrency, results)
 	}()
 
 	return results, nil
 }
 
+// parsePrefix returns the cidr of the ip or cidr query
+func parsePrefix(query string) (netip.Prefix, error) {
+	query = strings.TrimSpace(query)
+	if addr, err := netip.ParseAddr(query); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(query)
+	if err != nil {
+		return netip.Prefix{}, errors.New("only ip/cidr are accepted")
+	}
+	if prefix.Addr().Is6() && prefix.Addr().BitLen()-prefix.Bits() > maxIPv6HostBits {
+		return netip.Prefix{}, errorutil.NewWithTag("shodan-idb", "ipv6 cidr %s is too large, at most /%d is supported", query, 128-maxIPv6HostBits)
+	}
+	return prefix.Masked(), nil
+}
+
+// sweep looks up the addresses of the cidr with bounded concurrency until
+// limit results are emitted, all addresses are looked up if limit is zero
+func (agent *Agent) sweep(URL string, session *sources.Session, prefix netip.Prefix, limit, concurrency int, results chan sources.Result) {
+	ctx, cancel := context.WithCancel(session.Context())
+	defer cancel()
+	session = session.WithContext(ctx)
+
+	var emitted atomic.Int64
+	emit := func(result sources.Result) bool {
+		count := emitted.Add(1)
+		if limit > 0 && count > int64(limit) {
+			return false
+		}
+		results <- result
+		if limit > 0 && count == int64(limit) {
+			// stop the lookups of the remaining addresses
+			cancel()
+			return false
+		}
+		return true
+	}
+
+	addresses := make(chan string)
+	go func() {
+		defer close(addresses)
+		for addr := prefix.Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+			select {
+			case <-ctx.Done():
+				return
+			case addresses <- addr.String():
+			}
+		}
+	}()
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer session.Recover(agent.Name(), results)
+			for address := range addresses {
+				if ctx.Err() != nil {
+					continue
+				}
+				agent.query(URL, session, &ShodanRequest{Query: address}, emit, results)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func (agent *Agent) queryURL(session *sources.Session, URL string, shodanRequest *ShodanRequest) (*http.Response, error) {
 	shodanURL := fmt.Sprintf(URL, url.QueryEscape(shodanRequest.Query))
 	request, err := sources.NewHTTPRequest(http.MethodGet, shodanURL, nil)
@@ -49,44 +143,56 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, shodanRequest
 	return session.Do(request, agent.Name())
 }
 
-func (agent *Agent) query(URL string, session *sources.Session, shodanRequest *ShodanRequest, results chan sources.Result) {
-	var query string
-	if iputil.IsIP(shodanRequest.Query) {
-		if iputil.IsIPv4(shodanRequest.Query) {
-			query = iputil.AsIPV4CIDR(shodanRequest.Query)
-		} else if iputil.IsIPv6(shodanRequest.Query) {
-			query = iputil.AsIPV6CIDR(shodanRequest.Query)
-		}
-	} else {
-		query = shodanRequest.Query
-	}
-	ipChan, err := mapcidr.IPAddressesAsStream(query)
+// query sends the results of the address to emit until it returns false
+func (agent *Agent) query(URL string, session *sources.Session, shodanRequest *ShodanRequest, emit func(sources.Result) bool, results chan sources.Result) {
+	resp, err := agent.queryURL(session, URL, shodanRequest)
 	if err != nil {
-		results <- sources.Result{Source: agent.Name(), Error: err}
+		var statusErr *sources.StatusCodeError
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			// no information available for the address
+			return
+		}
+		if session.Context().Err() == nil {
+			results <- sources.Result{Source: agent.Name(), Error: err}
+		}
 		return
 	}
-	for ip := range ipChan {
-		resp, err := agent.queryURL(session, URL, &ShodanRequest{Query: ip})
-		if err != nil {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	shodanResponse := &ShodanResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(shodanResponse); err != nil {
+		if session.Context().Err() == nil {
 			results <- sources.Result{Source: agent.Name(), Error: err}
-			continue
 		}
+		return
+	}
 
-		shodanResponse := &ShodanResponse{}
-		if err := json.NewDecoder(resp.Body).Decode(shodanResponse); err != nil {
-			results <- sources.Result{Source: agent.Name(), Error: err}
+	result := sources.Result{
+		Source: agent.Name(),
+		IP:     shodanResponse.IP,
+		Vulns:  sources.NormalizeVulns(shodanResponse.Vulns),
+		CPEs:   shodanResponse.Cpes,
+		Tags:   shodanResponse.Tags,
+	}
+	result.Raw, _ = json.Marshal(shodanResponse)
+	// one result per port and hostname or per port if the ip has no hostname
+	for _, port := range shodanResponse.Ports {
+		result.Port = port
+		if len(shodanResponse.Hostnames) == 0 {
+			if !emit(result) {
+				return
+			}
 			continue
 		}
-
-		// we must output all combinations of ip/hostname with ports
-		result := sources.Result{Source: agent.Name(), IP: shodanResponse.IP}
-		result.Raw, _ = json.Marshal(shodanResponse)
-		for _, port := range shodanResponse.Ports {
-			result.Port = port
-			results <- result
-			for _, hostname := range shodanResponse.Hostnames {
-				result.Host = hostname
-				results <- result
+		for _, hostname := range shodanResponse.Hostnames {
+			result.Host = hostname
+			if !emit(result) {
+				return
 			}
 		}
 	}
@@ -0,0 +1,93 @@
+package shodanidb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+		err      string
+	}{
+		{query: " 1.1.1.1 ", expected: "1.1.1.1/32"},
+		{query: "1.1.1.7/24", expected: "1.1.1.0/24"},
+		{query: "2001:db8::1", expected: "2001:db8::1/128"},
+		{query: "2001:db8::/112", expected: "2001:db8::/112"},
+		{query: "2001:db8::/64", err: "too large"},
+		{query: "example.com", err: "only ip/cidr are accepted"},
+	}
+	for _, test := range tests {
+		prefix, err := parsePrefix(test.query)
+		if test.err != "" {
+			require.ErrorContains(t, err, test.err, test.query)
+			continue
+		}
+		require.Nil(t, err, test.query)
+		require.Equal(t, test.expected, prefix.String(), test.query)
+	}
+}
+
+func newTestServer(t *testing.T, requests *atomic.Int64) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/1.1.1.0" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"detail": "No information available"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ip": "` + r.URL.Path[1:] + `", "ports": [80, 443], "hostnames": []}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestSession(t *testing.T) *sources.Session {
+	session, err := sources.NewSessionWithOptions(&sources.SessionOptions{
+		Keys:       &sources.Keys{},
+		Engines:    []string{"shodan-idb"},
+		RateLimits: map[string]sources.RateLimit{"shodan-idb": {MaxCount: 1000, Duration: time.Second}},
+	})
+	require.Nil(t, err)
+	return session
+}
+
+func sweep(t *testing.T, server *httptest.Server, prefix string, limit int) []sources.Result {
+	results := make(chan sources.Result)
+	go func() {
+		defer close(results)
+		(&Agent{}).sweep(server.URL+"/%s", newTestSession(t), netip.MustParsePrefix(prefix), limit, 4, results)
+	}()
+	var all []sources.Result
+	for result := range results {
+		all = append(all, result)
+	}
+	return all
+}
+
+func TestSweep(t *testing.T) {
+	var requests atomic.Int64
+	server := newTestServer(t, &requests)
+
+	// addresses without information are skipped
+	results := sweep(t, server, "1.1.1.0/31", 0)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		require.Nil(t, result.Error)
+		require.Equal(t, "1.1.1.1", result.IP)
+	}
+
+	// lookups stop once the limit is reached
+	requests.Store(0)
+	results = sweep(t, server, "1.1.1.0/24", 5)
+	require.Len(t, results, 5)
+	require.Less(t, requests.Load(), int64(256))
+}
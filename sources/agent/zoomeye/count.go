@@ -0,0 +1,32 @@
+package zoomeye
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Count returns the total number of results of the query from a page with a single result
+func (agent *Agent) Count(session *sources.Session, query *sources.Query) (int, error) {
+	if session.Keys.ZoomEyeToken == "" {
+		return 0, errors.New("empty zoomeye keys")
+	}
+	subType, err := subType(query)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := agent.queryURL(session, URL, &ZoomEyeRequest{Query: query.Query, Page: 1, PageSize: 1, SubType: subType})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	zoomeyeResponse := &ZoomEyeResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(zoomeyeResponse); err != nil {
+		return 0, err
+	}
+	return zoomeyeResponse.Total, nil
+}
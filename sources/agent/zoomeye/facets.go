@@ -0,0 +1,58 @@
+package zoomeye
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+// Facets returns the total and the most common values of the facet fields
+// of the query (e.g. country, port, product) from a single result page
+func (agent *Agent) Facets(session *sources.Session, query *sources.Query, fields []string) (*sources.Facets, error) {
+	if session.Keys.ZoomEyeToken == "" {
+		return nil, errors.New("empty zoomeye keys")
+	}
+	names := make([]string, 0, len(fields))
+	sizes := make(map[string]int, len(fields))
+	for _, field := range fields {
+		name, size := sources.ParseFacetField(field)
+		names = append(names, name)
+		sizes[name] = size
+	}
+
+	subType, err := subType(query)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := agent.queryURL(session, URL, &ZoomEyeRequest{
+		Query:    query.Query,
+		Page:     1,
+		PageSize: 1,
+		SubType:  subType,
+		Facets:   strings.Join(names, ","),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	facetResponse := &FacetResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(facetResponse); err != nil {
+		return nil, err
+	}
+	facets := &sources.Facets{Total: facetResponse.Total}
+	for field, buckets := range facetResponse.Facets {
+		if size, ok := sizes[field]; ok && len(buckets) > size {
+			buckets = buckets[:size]
+		}
+		for _, bucket := range buckets {
+			facets.Add(field, fmt.Sprint(bucket.Name), bucket.Count)
+		}
+	}
+	return facets, nil
+}
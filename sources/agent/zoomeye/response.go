@@ -1,12 +1,33 @@
 package zoomeye
 
+import "encoding/json"
+
 type ZoomEyeResponse struct {
-	Total   int             `json:"total"`
-	Results []ZoomEyeResult `json:"data"`
+	Total int `json:"total"`
+	// Results are kept raw to preserve the requested fields
+	Results []json.RawMessage `json:"data"`
 }
 
 type ZoomEyeResult struct {
-	IP       string `json:"ip"`
-	Port     int    `json:"port"`
-	Hostname string `json:"hostname"`
+	IP           string      `json:"ip"`
+	Port         int         `json:"port"`
+	Hostname     string      `json:"hostname"`
+	Domain       string      `json:"domain"`
+	URL          string      `json:"url"`
+	Product      string      `json:"product"`
+	Service      string      `json:"service"`
+	Country      string      `json:"country.name"`
+	City         string      `json:"city.name"`
+	Organization string      `json:"organization.name"`
+	ASN          interface{} `json:"asn"`
+}
+
+type FacetResponse struct {
+	Total  int                      `json:"total"`
+	Facets map[string][]FacetBucket `json:"facets"`
+}
+
+type FacetBucket struct {
+	Name  interface{} `json:"name"`
+	Count int         `json:"count"`
 }
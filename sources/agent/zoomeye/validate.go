@@ -0,0 +1,20 @@
+package zoomeye
+
+import (
+	"net/http"
+
+	"github.com/projectdiscovery/uncover/sources"
+)
+
+const UserInfoURL = "https://api.zoomeye.ai/v2/userinfo"
+
+// ValidateKey checks the zoomeye key with the user info endpoint
+func (agent *Agent) ValidateKey(session *sources.Session) error {
+	request, err := sources.NewHTTPRequest(http.MethodPost, UserInfoURL, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("API-KEY", session.Keys.ZoomEyeToken)
+	_, err = session.ValidateKey(request, agent.Name())
+	return err
+}
@@ -5,36 +5,68 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"errors"
 
 	"github.com/projectdiscovery/uncover/sources"
+	errorutil "github.com/projectdiscovery/utils/errors"
 )
 
 var (
 	URL = "https://api.zoomeye.ai/v2/search"
+
+	// PageSize is the maximum number of results to return per page
+	PageSize = 100
+	// SubType is the type of assets to search (v4, v6 or web), zoomeye uses v4 if empty
+	SubType = ""
+	// Fields is the fields to return in the results
+	Fields = "ip,port,hostname,domain,url,title,product,service,country.name,city.name,organization.name,asn,ssl"
 )
 
+// subTypes are the asset types accepted by zoomeye
+var subTypes = []string{"v4", "v6", "web"}
+
 type Agent struct{}
 
 type ZoomEyeRequest struct {
 	Query    string
 	Page     int
 	PageSize int
+	SubType  string
+	Fields   string
+	Facets   string
 }
 
 func (agent *Agent) Name() string {
 	return "zoomeye"
 }
 
+// QueryOptions returns the names of the supported query options
+func (agent *Agent) QueryOptions() []string {
+	return []string{"sub_type", "fields"}
+}
+
+// Query searches zoomeye, the sub_type and fields options of the query
+// override the package defaults
 func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan sources.Result, error) {
 	if session.Keys.ZoomEyeToken == "" {
 		return nil, errors.New("empty zoomeye keys")
 	}
+	subType, err := subType(query)
+	if err != nil {
+		return nil, err
+	}
+	fields := query.Option("fields", Fields)
+	// pages of zoomeye have a fixed size, they are sized to not spend credits on discarded results
+	pageSize := sources.PageSize(query.Limit, PageSize)
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
+		defer session.Recover(agent.Name(), results)
 
 		currentPage := 1
 		var numberOfResults, totalResults int
@@ -42,17 +74,20 @@ func (agent *Agent) Query(session *sources.Session, query *sources.Query) (chan
 			zoomeyeRequest := &ZoomEyeRequest{
 				Query:    query.Query,
 				Page:     currentPage,
-				PageSize: 100,
+				PageSize: pageSize,
+				SubType:  subType,
+				Fields:   fields,
 			}
 
-			zoomeyeResponse := agent.query(URL, session, zoomeyeRequest, results)
+			zoomeyeResponse, emitted := agent.query(URL, session, zoomeyeRequest, query.Limit-numberOfResults, results)
 			if zoomeyeResponse == nil {
 				break
 			}
 			currentPage++
-			numberOfResults += len(zoomeyeResponse.Results)
+			numberOfResults += emitted
 			if totalResults == 0 {
 				totalResults = zoomeyeResponse.Total
+				session.Stats.SetTotal(agent.Name(), query.Query, totalResults)
 			}
 
 			if numberOfResults >= query.Limit || numberOfResults >= totalResults || len(zoomeyeResponse.Results) == 0 {
@@ -73,6 +108,15 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, zoomeyeReques
 		"page":     zoomeyeRequest.Page,
 		"pagesize": zoomeyeRequest.PageSize,
 	}
+	if zoomeyeRequest.SubType != "" {
+		requestBody["sub_type"] = zoomeyeRequest.SubType
+	}
+	if zoomeyeRequest.Fields != "" {
+		requestBody["fields"] = zoomeyeRequest.Fields
+	}
+	if zoomeyeRequest.Facets != "" {
+		requestBody["facets"] = zoomeyeRequest.Facets
+	}
 
 	jsonBody, err := json.Marshal(requestBody)
 	if err != nil {
@@ -88,11 +132,13 @@ func (agent *Agent) queryURL(session *sources.Session, URL string, zoomeyeReques
 	return session.Do(request, agent.Name())
 }
 
-func (agent *Agent) query(URL string, session *sources.Session, zoomeyeRequest *ZoomEyeRequest, results chan sources.Result) *ZoomEyeResponse {
+// query emits at most limit results of a page and returns the page
+// with the number of emitted results
+func (agent *Agent) query(URL string, session *sources.Session, zoomeyeRequest *ZoomEyeRequest, limit int, results chan sources.Result) (*ZoomEyeResponse, int) {
 	resp, err := agent.queryURL(session, URL, zoomeyeRequest)
 	if err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: err}
-		return nil
+		return nil, 0
 	}
 	defer func() {
 		_ = resp.Body.Close()
@@ -101,20 +147,63 @@ func (agent *Agent) query(URL string, session *sources.Session, zoomeyeRequest *
 	zoomeyeResponse := &ZoomEyeResponse{}
 	if err := json.NewDecoder(resp.Body).Decode(zoomeyeResponse); err != nil {
 		results <- sources.Result{Source: agent.Name(), Error: err}
-		return nil
+		return nil, 0
 	}
 
-	for _, result := range zoomeyeResponse.Results {
-		sourceResult := sources.Result{Source: agent.Name()}
-
-		sourceResult.IP = result.IP
-		sourceResult.Port = result.Port
-		sourceResult.Host = result.Hostname
+	var emitted int
+	for _, raw := range zoomeyeResponse.Results {
+		if emitted >= limit {
+			break
+		}
+		result := ZoomEyeResult{}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			results <- sources.Result{Source: agent.Name(), Error: err}
+			continue
+		}
 
-		raw, _ := json.Marshal(result)
-		sourceResult.Raw = raw
+		sourceResult := sources.Result{
+			Source:  agent.Name(),
+			IP:      result.IP,
+			Port:    result.Port,
+			Host:    result.Hostname,
+			Url:     result.URL,
+			Service: result.Service,
+			Country: result.Country,
+			City:    result.City,
+			Org:     result.Organization,
+			ASN:     result.asn(),
+			Raw:     raw,
+		}
+		if sourceResult.Host == "" {
+			sourceResult.Host = result.Domain
+		}
+		if result.Product != "" {
+			sourceResult.Software = []string{result.Product}
+		}
 		results <- sourceResult
+		emitted++
 	}
 
-	return zoomeyeResponse
+	return zoomeyeResponse, emitted
+}
+
+// subType returns the validated sub_type option of the query
+func subType(query *sources.Query) (string, error) {
+	subType := strings.ToLower(query.Option("sub_type", SubType))
+	if subType != "" && !slices.Contains(subTypes, subType) {
+		return "", errorutil.NewWithTag("zoomeye", "invalid sub type %s, expected one of %s", subType, strings.Join(subTypes, ","))
+	}
+	return subType, nil
+}
+
+// asn returns the autonomous system number which zoomeye returns as number or string
+func (result *ZoomEyeResult) asn() int {
+	switch asn := result.ASN.(type) {
+	case float64:
+		return int(asn)
+	case string:
+		number, _ := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(asn)), "AS"))
+		return number
+	}
+	return 0
 }
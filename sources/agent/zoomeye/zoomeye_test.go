@@ -0,0 +1,88 @@
+package zoomeye
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubType(t *testing.T) {
+	tests := []struct {
+		options  map[string]string
+		expected string
+		err      bool
+	}{
+		{expected: SubType},
+		{options: map[string]string{"sub_type": "V6"}, expected: "v6"},
+		{options: map[string]string{"sub_type": "web"}, expected: "web"},
+		{options: map[string]string{"sub_type": "host"}, err: true},
+	}
+	for _, test := range tests {
+		subType, err := subType(&sources.Query{Options: test.options})
+		if test.err {
+			require.ErrorContains(t, err, "invalid sub type")
+			continue
+		}
+		require.Nil(t, err)
+		require.Equal(t, test.expected, subType)
+	}
+}
+
+func TestASN(t *testing.T) {
+	tests := []struct {
+		asn      interface{}
+		expected int
+	}{
+		{asn: float64(13335), expected: 13335},
+		{asn: "AS13335", expected: 13335},
+		{asn: " as15169", expected: 15169},
+		{asn: "13335", expected: 13335},
+		{asn: "", expected: 0},
+		{asn: nil, expected: 0},
+	}
+	for _, test := range tests {
+		result := &ZoomEyeResult{ASN: test.asn}
+		require.Equal(t, test.expected, result.asn(), test.asn)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.Header.Get("API-KEY"))
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		_, _ = w.Write([]byte(`{"total": 3, "data": [
+			{"ip": "1.1.1.1", "port": 443, "hostname": "a.example.com", "product": "nginx", "country.name": "US", "asn": "AS13335"},
+			{"ip": "2.2.2.2", "port": 80, "domain": "b.example.com", "asn": 15169},
+			{"ip": "3.3.3.3", "port": 22}
+		]}`))
+	}))
+	defer server.Close()
+
+	session, err := sources.NewSessionWithOptions(&sources.SessionOptions{Keys: &sources.Keys{ZoomEyeToken: "key"}, Engines: []string{"zoomeye"}})
+	require.Nil(t, err)
+	results := make(chan sources.Result, 10)
+	response, emitted := (&Agent{}).query(server.URL, session, &ZoomEyeRequest{Query: "app:nginx", Page: 1, PageSize: 2, SubType: "v4", Fields: Fields}, 2, results)
+	close(results)
+	require.NotNil(t, response)
+	require.Equal(t, 2, emitted)
+	require.Equal(t, "v4", request["sub_type"])
+	require.Equal(t, float64(2), request["pagesize"])
+
+	var all []sources.Result
+	for result := range results {
+		all = append(all, result)
+	}
+	require.Len(t, all, 2)
+	require.Equal(t, "a.example.com", all[0].Host)
+	require.Equal(t, []string{"nginx"}, all[0].Software)
+	require.Equal(t, 13335, all[0].ASN)
+	require.Equal(t, "US", all[0].Country)
+	// the domain is used as host of results without hostname
+	require.Equal(t, "b.example.com", all[1].Host)
+	require.Equal(t, 15169, all[1].ASN)
+}
@@ -0,0 +1,54 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryOptions(t *testing.T) {
+	query := &Query{Options: map[string]string{"full": "true", "size": "500", "page": "many"}}
+
+	require.Equal(t, "500", query.Option("size", "100"))
+	require.Equal(t, "ip,port", query.Option("fields", "ip,port"))
+
+	full, err := query.BoolOption("full", false)
+	require.Nil(t, err)
+	require.True(t, full)
+	next, err := query.BoolOption("next", true)
+	require.Nil(t, err)
+	require.True(t, next)
+	_, err = query.BoolOption("size", false)
+	require.ErrorContains(t, err, "expected true or false")
+
+	size, err := query.IntOption("size", 100)
+	require.Nil(t, err)
+	require.Equal(t, 500, size)
+	_, err = query.IntOption("page", 1)
+	require.ErrorContains(t, err, "expected a number")
+
+	// queries without options use the defaults
+	size, err = (&Query{}).IntOption("size", 100)
+	require.Nil(t, err)
+	require.Equal(t, 100, size)
+}
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		limit, maxSize, expected int
+	}{
+		{limit: 0, maxSize: 100, expected: 1},
+		{limit: 50, maxSize: 100, expected: 50},
+		{limit: 100, maxSize: 100, expected: 100},
+		{limit: 101, maxSize: 100, expected: 51},
+		{limit: 150, maxSize: 100, expected: 75},
+		{limit: 1000, maxSize: 100, expected: 100},
+		{limit: 1001, maxSize: 100, expected: 91},
+	}
+	for _, test := range tests {
+		size := PageSize(test.limit, test.maxSize)
+		require.Equal(t, test.expected, size, test.limit)
+		pages := (max(test.limit, 1) + size - 1) / size
+		require.Less(t, pages*size-max(test.limit, 1), pages, test.limit)
+	}
+}
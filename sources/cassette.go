@@ -0,0 +1,77 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+// Interaction is a recorded http request of an engine with its response,
+// e.g. a cassette of a test, with all known secrets redacted
+type Interaction struct {
+	Engine          string            `json:"engine"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	StatusCode      int               `json:"status_code"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+}
+
+// Recorder receives the redacted interactions of a session
+type Recorder func(interaction *Interaction)
+
+// NewCassetteRecorder returns a recorder writing interactions to w as JSON lines
+func NewCassetteRecorder(w io.Writer) Recorder {
+	mu := &sync.Mutex{}
+	return func(interaction *Interaction) {
+		data, err := json.Marshal(interaction)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(append(data, '\n'))
+	}
+}
+
+// record passes the redacted interaction of the request to the recorder of the session,
+// the response body is read and replaced by an in memory copy
+func (s *Session) record(request *retryablehttp.Request, resp *http.Response, source string) {
+	requestBody, _ := request.BodyBytes()
+	responseBody, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	s.Recorder(&Interaction{
+		Engine:          source,
+		Method:          request.Method,
+		URL:             s.Redactor.Redact(RedactURL(request.String())),
+		RequestHeaders:  s.recordHeaders(request.Header),
+		RequestBody:     s.Redactor.Redact(string(requestBody)),
+		StatusCode:      resp.StatusCode,
+		ResponseHeaders: s.recordHeaders(resp.Header),
+		ResponseBody:    s.Redactor.Redact(string(responseBody)),
+	})
+}
+
+func (s *Session) recordHeaders(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(header))
+	for name := range header {
+		value := strings.Join(header.Values(name), ", ")
+		if isSensitiveHeader(name) {
+			value = Redacted
+		}
+		headers[name] = s.Redactor.Redact(value)
+	}
+	return headers
+}
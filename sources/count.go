@@ -0,0 +1,8 @@
+package sources
+
+// CountAgent is implemented by agents of engines which can return the total
+// number of results of a query without retrieving them
+type CountAgent interface {
+	// Count returns the total number of results of the query with the cheapest request of the engine
+	Count(session *Session, query *Query) (int, error)
+}
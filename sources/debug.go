@@ -0,0 +1,96 @@
+package sources
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+// maxDebugBodySize is the maximum number of body bytes logged per request or response
+const maxDebugBodySize = 4096
+
+// maxErrorBodySize is the maximum number of body bytes included in error messages
+const maxErrorBodySize = 256
+
+// sensitiveHeaderParts are parts of header names whose values are redacted in debug logs
+var sensitiveHeaderParts = []string{"key", "token", "auth", "cookie", "secret"}
+
+// debugRequest logs an http request of an engine with its credentials redacted
+func (s *Session) debugRequest(request *retryablehttp.Request, source string) {
+	body, _ := request.BodyBytes()
+	dump := &strings.Builder{}
+	_, _ = fmt.Fprintf(dump, "%s request: %s %s\n", source, request.Method, RedactURL(request.String()))
+	writeDebugHeaders(dump, request.Header)
+	writeDebugBody(dump, body)
+	gologger.Debug().Str("engine", source).Str("type", "request").Msg(s.Redactor.Redact(dump.String()))
+}
+
+// debugResponse logs an http response of an engine with its credentials redacted,
+// the response body is read and replaced by an in memory copy
+func (s *Session) debugResponse(resp *http.Response, source string) {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	dump := &strings.Builder{}
+	_, _ = fmt.Fprintf(dump, "%s response: %s %s\n", source, resp.Status, RedactURL(resp.Request.URL.String()))
+	writeDebugHeaders(dump, resp.Header)
+	writeDebugBody(dump, body)
+	if err != nil {
+		_, _ = fmt.Fprintf(dump, "could not read body: %s\n", err)
+	}
+	gologger.Debug().Str("engine", source).Str("type", "response").Msg(s.Redactor.Redact(dump.String()))
+}
+
+func writeDebugHeaders(dump *strings.Builder, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(header.Values(name), ", ")
+		if isSensitiveHeader(name) {
+			value = Redacted
+		}
+		_, _ = fmt.Fprintf(dump, "%s: %s\n", name, value)
+	}
+}
+
+func writeDebugBody(dump *strings.Builder, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	dump.WriteString("\n")
+	if len(body) > maxDebugBodySize {
+		_, _ = fmt.Fprintf(dump, "%s\n[truncated %d bytes]\n", body[:maxDebugBodySize], len(body)-maxDebugBodySize)
+		return
+	}
+	dump.Write(body)
+	dump.WriteString("\n")
+}
+
+// TruncateBody returns the body of a response for error messages,
+// bodies larger than maxErrorBodySize bytes are truncated
+func TruncateBody(body []byte) string {
+	if len(body) > maxErrorBodySize {
+		return fmt.Sprintf("%s... [truncated %d bytes]", body[:maxErrorBodySize], len(body)-maxErrorBodySize)
+	}
+	return string(body)
+}
+
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range sensitiveHeaderParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package sources
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/gologger/formatter"
+	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/gologger/writer"
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/stretchr/testify/require"
+)
+
+type bufferWriter struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (w *bufferWriter) Write(data []byte, _ levels.Level) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffer.Write(data)
+	w.buffer.WriteString("\n")
+}
+
+func (w *bufferWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.String()
+}
+
+func TestDebugExchangesRedacted(t *testing.T) {
+	const secret = "s3cr3t-shodan-key"
+	output := &bufferWriter{}
+	gologger.DefaultLogger.SetWriter(output)
+	gologger.DefaultLogger.SetFormatter(&formatter.JSON{})
+	gologger.DefaultLogger.SetMaxLevel(levels.LevelDebug)
+	defer func() {
+		gologger.DefaultLogger.SetWriter(writer.NewCLI())
+		gologger.DefaultLogger.SetFormatter(formatter.NewCLI(false))
+		gologger.DefaultLogger.SetMaxLevel(levels.LevelInfo)
+	}()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session="+secret)
+		_, _ = w.Write([]byte(`{"error":"invalid key ` + r.URL.Query().Get("key") + `","matches":[]}`))
+	}))
+	defer ts.Close()
+
+	session, err := NewSession(&Keys{Shodan: secret}, 0, 5, 0, []string{"shodan"}, time.Second, "")
+	require.Nil(t, err)
+	session.DebugRequests = true
+	session.DebugResponses = true
+
+	req, err := retryablehttp.NewRequest(http.MethodPost, ts.URL+"/search?key="+secret+"&query=ssl", strings.NewReader(`{"token":"`+secret+`"}`))
+	require.Nil(t, err)
+	req.Header.Set("X-API-Key", secret)
+	resp, err := session.Do(req, "shodan")
+	require.Nil(t, err)
+
+	// the response body is still readable by agents
+	body := &bytes.Buffer{}
+	_, err = body.ReadFrom(resp.Body)
+	require.Nil(t, err)
+	require.Contains(t, body.String(), secret)
+
+	logged := output.String()
+	require.NotContains(t, logged, secret)
+	require.Contains(t, logged, `"engine":"shodan"`)
+	require.Contains(t, logged, `"type":"request"`)
+	require.Contains(t, logged, `"type":"response"`)
+	require.Contains(t, logged, "key=REDACTED")
+	require.Contains(t, logged, "X-Api-Key: REDACTED")
+	require.Contains(t, logged, "200 OK")
+}
+
+func TestTruncateBody(t *testing.T) {
+	require.Equal(t, "short", TruncateBody([]byte("short")))
+	truncated := TruncateBody([]byte(strings.Repeat("a", maxErrorBodySize+10)))
+	require.Equal(t, strings.Repeat("a", maxErrorBodySize)+"... [truncated 10 bytes]", truncated)
+}
@@ -0,0 +1,48 @@
+package sources
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DefaultFacetSize is the number of values returned per facet field without size
+const DefaultFacetSize = 10
+
+// FacetValue is the number of results with a value of a facet field
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets contains the total number of results of a query and
+// the most common values of its facet fields
+type Facets struct {
+	Total  int                     `json:"total"`
+	Fields map[string][]FacetValue `json:"fields"`
+}
+
+// FacetAgent is implemented by agents of engines with an aggregation api
+type FacetAgent interface {
+	// Facets returns the most common values of the fields (e.g. port, country:20)
+	// of the results of the query
+	Facets(session *Session, query *Query, fields []string) (*Facets, error)
+}
+
+// ParseFacetField returns the name and size of a facet field in name[:size] format
+func ParseFacetField(field string) (string, int) {
+	name, size, ok := strings.Cut(strings.TrimSpace(field), ":")
+	if ok {
+		if n, err := strconv.Atoi(size); err == nil && n > 0 {
+			return name, n
+		}
+	}
+	return name, DefaultFacetSize
+}
+
+// Add adds the count of a value of a facet field
+func (facets *Facets) Add(field, value string, count int) {
+	if facets.Fields == nil {
+		facets.Fields = make(map[string][]FacetValue)
+	}
+	facets.Fields[field] = append(facets.Fields[field], FacetValue{Value: value, Count: count})
+}
@@ -0,0 +1,28 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFacetField(t *testing.T) {
+	for field, expected := range map[string]struct {
+		name string
+		size int
+	}{
+		"port":         {"port", DefaultFacetSize},
+		" country:20 ": {"country", 20},
+		"org:0":        {"org", DefaultFacetSize},
+		"org:many":     {"org", DefaultFacetSize},
+	} {
+		name, size := ParseFacetField(field)
+		require.Equal(t, expected.name, name, field)
+		require.Equal(t, expected.size, size, field)
+	}
+
+	facets := &Facets{}
+	facets.Add("port", "443", 10)
+	facets.Add("port", "80", 5)
+	require.Equal(t, []FacetValue{{Value: "443", Count: 10}, {Value: "80", Count: 5}}, facets.Fields["port"])
+}
@@ -0,0 +1,143 @@
+package sources
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// HTTPOptions contains the http client configuration of engines
+type HTTPOptions struct {
+	// Proxy is the http, https or socks5 proxy url, env proxy settings are used if empty
+	Proxy string `yaml:"proxy,omitempty"`
+	// CACert is a PEM file of CA certificates trusted in addition to the system ones
+	CACert string `yaml:"ca-cert,omitempty"`
+	// Insecure disables tls certificate verification
+	Insecure bool `yaml:"insecure,omitempty"`
+	// Headers are extra headers sent with all requests
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// UserAgent replaces the default user agent
+	UserAgent string `yaml:"user-agent,omitempty"`
+}
+
+// supportedProxySchemes are the proxy url schemes supported by the http transport
+var supportedProxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// Merge returns the options with unset fields taken from defaults,
+// headers of both options are combined
+func (options HTTPOptions) Merge(defaults HTTPOptions) HTTPOptions {
+	merged := options
+	if merged.Proxy == "" {
+		merged.Proxy = defaults.Proxy
+	}
+	if merged.CACert == "" {
+		merged.CACert = defaults.CACert
+	}
+	if merged.UserAgent == "" {
+		merged.UserAgent = defaults.UserAgent
+	}
+	merged.Insecure = merged.Insecure || defaults.Insecure
+	if len(defaults.Headers) > 0 {
+		merged.Headers = maps.Clone(defaults.Headers)
+		maps.Copy(merged.Headers, options.Headers)
+	}
+	return merged
+}
+
+// Validate validates the proxy url and CA file of the options
+func (options HTTPOptions) Validate() error {
+	if options.Proxy != "" {
+		proxyURL, err := url.Parse(options.Proxy)
+		if err != nil {
+			return errorutil.NewWithErr(err).Msgf("invalid proxy %s", options.Proxy)
+		}
+		if !contains(supportedProxySchemes, proxyURL.Scheme) || proxyURL.Host == "" {
+			return errorutil.NewWithTag("uncover", "invalid proxy %s, supported schemes are http, https, socks5 and socks5h", options.Proxy)
+		}
+	}
+	if options.CACert != "" {
+		if _, err := options.rootCAs(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transportKey identifies the options sharing the same transport and connection pool
+func (options HTTPOptions) transportKey() string {
+	return fmt.Sprintf("%s|%s|%t", options.Proxy, options.CACert, options.Insecure)
+}
+
+// rootCAs returns the system certificate pool with the CA certificates of the options
+func (options HTTPOptions) rootCAs() (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	data, err := os.ReadFile(options.CACert)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not read ca certificates %s", options.CACert)
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errorutil.NewWithTag("uncover", "no ca certificate found in %s", options.CACert)
+	}
+	return pool, nil
+}
+
+// newTransport creates the http transport of the options
+func (options HTTPOptions) newTransport(timeout time.Duration) (*http.Transport, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: options.Insecure}
+	if options.CACert != "" {
+		pool, err := options.rootCAs()
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	proxy := http.ProxyFromEnvironment
+	if options.Proxy != "" {
+		proxyURL, err := url.Parse(options.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Transport{
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		TLSClientConfig:       tlsConfig,
+		ResponseHeaderTimeout: timeout,
+		Proxy:                 proxy,
+	}, nil
+}
+
+// headerTransport adds the configured headers and user agent to requests
+type headerTransport struct {
+	base      http.RoundTripper
+	headers   map[string]string
+	userAgent string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.headers) == 0 && t.userAgent == "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		// headers set by agents (e.g. credentials) take precedence
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
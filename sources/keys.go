@@ -1,5 +1,7 @@
 package sources
 
+import "reflect"
+
 type Keys struct {
 	CensysToken     string
 	CensysOrgId     string
@@ -45,3 +47,15 @@ func (keys Keys) Empty() bool {
 		keys.GreyNoiseKey == "" &&
 		keys.NerdyDataToken == ""
 }
+
+// Secrets returns all non empty key values
+func (keys Keys) Secrets() []string {
+	var secrets []string
+	value := reflect.ValueOf(keys)
+	for i := 0; i < value.NumField(); i++ {
+		if secret := value.Field(i).String(); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
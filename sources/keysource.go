@@ -0,0 +1,275 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	errorutil "github.com/projectdiscovery/utils/errors"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// KeystorePassphraseEnv is the env variable holding the passphrase of the encrypted keystore
+const KeystorePassphraseEnv = "UNCOVER_KEYSTORE_PASSPHRASE"
+
+// keyCmdTimeout is the maximum time a key command may run
+const keyCmdTimeout = 30 * time.Second
+
+// keystoreVersion is the version of the encrypted keystore format
+const keystoreVersion = 1
+
+// scrypt parameters used to derive the keystore encryption key
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltSize     = 16
+)
+
+// keystoreFile is the on disk format of the encrypted keystore
+type keystoreFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// keys returns the key list of the provider with given name
+func (provider *Provider) keys(name string) *[]string {
+	value := reflect.ValueOf(provider).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() || yamlName(field) != name {
+			continue
+		}
+		if keys, ok := value.Field(i).Addr().Interface().(*[]string); ok {
+			return keys
+		}
+	}
+	return nil
+}
+
+// Names returns the names of all providers
+func (provider *Provider) Names() []string {
+	var names []string
+	value := reflect.ValueOf(provider).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.IsExported() && field.Type == reflect.TypeOf([]string(nil)) {
+			names = append(names, yamlName(field))
+		}
+	}
+	return names
+}
+
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	return name
+}
+
+// loadKeySources resolves the keys of the key commands, key files
+// and encrypted keystore referenced by the provider config, keys
+// already known to the provider are not added again
+func (provider *Provider) loadKeySources() error {
+	var errs []error
+	for name, command := range provider.KeyCmd {
+		keys := provider.keys(name)
+		if keys == nil {
+			errs = append(errs, errorutil.NewWithTag("uncover", "unknown provider %s in key_cmd", name))
+			continue
+		}
+		key, err := runKeyCmd(command)
+		if err != nil {
+			errs = append(errs, errorutil.NewWithErr(err).Msgf("could not run key_cmd of %s", name))
+			continue
+		}
+		appendKeys(keys, key)
+		provider.setOrigin(OriginKeyCmd)
+	}
+	for name, location := range provider.KeyFile {
+		keys := provider.keys(name)
+		if keys == nil {
+			errs = append(errs, errorutil.NewWithTag("uncover", "unknown provider %s in key_file", name))
+			continue
+		}
+		fileKeys, err := readKeyFile(location)
+		if err != nil {
+			errs = append(errs, errorutil.NewWithErr(err).Msgf("could not read key_file of %s", name))
+			continue
+		}
+		appendKeys(keys, fileKeys...)
+		provider.setOrigin(OriginKeyFile)
+	}
+	if provider.Keystore != "" {
+		passphrase, ok := os.LookupEnv(KeystorePassphraseEnv)
+		if !ok {
+			errs = append(errs, errorutil.NewWithTag("uncover", "keystore %s configured but %s is not set", provider.Keystore, KeystorePassphraseEnv))
+		} else if keystore, err := ReadKeystore(expandHome(provider.Keystore), passphrase); err != nil {
+			errs = append(errs, err)
+		} else {
+			provider.merge(keystore)
+			provider.setOrigin(OriginKeystore)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// merge appends the keys of all providers of other
+func (provider *Provider) merge(other *Provider) {
+	for _, name := range other.Names() {
+		appendKeys(provider.keys(name), *other.keys(name)...)
+	}
+}
+
+// appendKeys appends the keys missing from the key list, loading
+// key sources again does not duplicate their keys
+func appendKeys(keys *[]string, values ...string) {
+	for _, value := range values {
+		if !contains(*keys, value) {
+			*keys = append(*keys, value)
+		}
+	}
+}
+
+// runKeyCmd runs a shell command and returns the first line of its output as key
+func runKeyCmd(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyCmdTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	key, _, _ := strings.Cut(string(output), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errorutil.NewWithTag("uncover", "command returned no key")
+	}
+	return key, nil
+}
+
+// readKeyFile returns the non empty lines of the file as keys
+func readKeyFile(location string) ([]string, error) {
+	data, err := os.ReadFile(expandHome(location))
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if key := strings.TrimSpace(scanner.Text()); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errorutil.NewWithTag("uncover", "no key found in %s", location)
+	}
+	return keys, scanner.Err()
+}
+
+func expandHome(location string) string {
+	if rest, ok := strings.CutPrefix(location, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return location
+}
+
+// ReadKeystore decrypts the provider keys of the keystore at given location
+func ReadKeystore(location, passphrase string) (*Provider, error) {
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not read keystore %s", location)
+	}
+	var keystore keystoreFile
+	if err := json.Unmarshal(data, &keystore); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("invalid keystore %s", location)
+	}
+	if keystore.Version != keystoreVersion || keystore.KDF != "scrypt" {
+		return nil, errorutil.NewWithTag("uncover", "unsupported keystore version %d", keystore.Version)
+	}
+	gcm, err := keystoreCipher(passphrase, keystore.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, keystore.Nonce, keystore.Data, nil)
+	if err != nil {
+		return nil, errorutil.NewWithTag("uncover", "could not decrypt keystore %s: wrong passphrase or corrupted file", location)
+	}
+	provider := &Provider{}
+	if err := yaml.Unmarshal(plaintext, provider); err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("invalid keystore %s", location)
+	}
+	return provider, nil
+}
+
+// WriteKeystore encrypts the provider keys with given passphrase and writes them to location
+func WriteKeystore(location, passphrase string, provider *Provider) error {
+	if passphrase == "" {
+		return errorutil.NewWithTag("uncover", "keystore passphrase cannot be empty")
+	}
+	keys := &Provider{}
+	keys.merge(provider)
+	plaintext, err := yaml.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := keystoreCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(keystoreFile{
+		Version: keystoreVersion,
+		KDF:     "scrypt",
+		Salt:    salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(location, data, 0600)
+}
+
+func keystoreCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderKeySources(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "censys")
+	require.Nil(t, os.WriteFile(keyFile, []byte("token-1:org-1\n\ntoken-2:org-2\n"), 0600))
+
+	keystore := filepath.Join(dir, "keystore.enc")
+	require.Nil(t, WriteKeystore(keystore, "passphrase", &Provider{Fofa: []string{"user@example.com:fofa-key"}}))
+	data, err := os.ReadFile(keystore)
+	require.Nil(t, err)
+	require.NotContains(t, string(data), "fofa-key")
+
+	config := filepath.Join(dir, "provider-config.yaml")
+	require.Nil(t, os.WriteFile(config, []byte(`shodan:
+  - plain-key
+key_cmd:
+  shodan: "printf 'cmd-key\\nmetadata ignored\\n'"
+key_file:
+  censys: `+keyFile+`
+keystore: `+keystore+`
+`), 0600))
+
+	t.Setenv(KeystorePassphraseEnv, "passphrase")
+	provider := &Provider{}
+	require.Nil(t, provider.LoadProviderConfig(config))
+	require.Equal(t, []string{"plain-key", "cmd-key"}, provider.Shodan)
+	require.Equal(t, []string{"token-1:org-1", "token-2:org-2"}, provider.Censys)
+	require.Equal(t, []string{"user@example.com:fofa-key"}, provider.Fofa)
+
+	// reloading the key sources does not duplicate their keys
+	require.Nil(t, provider.loadKeySources())
+	require.Equal(t, []string{"plain-key", "cmd-key"}, provider.Shodan)
+	require.Equal(t, []string{"token-1:org-1", "token-2:org-2"}, provider.Censys)
+	require.Equal(t, []string{"user@example.com:fofa-key"}, provider.Fofa)
+
+	t.Setenv(KeystorePassphraseEnv, "wrong")
+	provider = &Provider{}
+	err = provider.LoadProviderConfig(config)
+	require.ErrorContains(t, err, "wrong passphrase")
+	// keys of the other sources are still loaded
+	require.Equal(t, []string{"plain-key", "cmd-key"}, provider.Shodan)
+}
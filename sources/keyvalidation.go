@@ -0,0 +1,60 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+// ErrInvalidKey is returned by key validations of invalid or expired keys
+var ErrInvalidKey = errors.New("invalid or expired key")
+
+// KeyValidator is implemented by agents able to check their keys
+// with the cheapest authenticated request of the engine
+type KeyValidator interface {
+	ValidateKey(session *Session) error
+}
+
+// WithKeys returns a shallow copy of the session using given keys
+func (s *Session) WithKeys(keys *Keys) *Session {
+	session := *s
+	session.Keys = keys
+	return &session
+}
+
+// ValidateKey sends the key validation request of an engine and returns the response body,
+// unauthorized responses are reported as ErrInvalidKey
+func (s *Session) ValidateKey(request *retryablehttp.Request, source string) ([]byte, error) {
+	resp, err := s.Do(request, source)
+	if resp != nil {
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+	}
+	var statusErr *StatusCodeError
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden) {
+		return nil, fmt.Errorf("%w: status code %d", ErrInvalidKey, statusErr.StatusCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// MaskKey masks all parts of a key (e.g. email:key) showing only
+// the first and last characters of long parts
+func MaskKey(key string) string {
+	parts := strings.Split(key, ":")
+	for i, part := range parts {
+		if len(part) <= 8 {
+			parts[i] = "****"
+			continue
+		}
+		parts[i] = part[:4] + "****" + part[len(part)-4:]
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,108 @@
+package sources
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionValidateKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Key") {
+		case "valid-key":
+			_, _ = w.Write([]byte(`{"requests_left":100}`))
+		case "":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	session, err := NewSession(&Keys{}, 0, 5, 0, []string{"binaryedge"}, time.Second, "")
+	require.Nil(t, err)
+	validate := func(key string) ([]byte, error) {
+		request, err := retryablehttp.NewRequest(http.MethodGet, ts.URL, nil)
+		require.Nil(t, err)
+		if key != "" {
+			request.Header.Set("X-Key", key)
+		}
+		return session.ValidateKey(request, "binaryedge")
+	}
+
+	body, err := validate("valid-key")
+	require.Nil(t, err)
+	require.JSONEq(t, `{"requests_left":100}`, string(body))
+
+	_, err = validate("expired-key")
+	require.True(t, errors.Is(err, ErrInvalidKey))
+
+	_, err = validate("")
+	require.NotNil(t, err)
+	require.False(t, errors.Is(err, ErrInvalidKey))
+}
+
+func TestProviderKeyManagement(t *testing.T) {
+	t.Setenv("SHODAN_API_KEY", "env-shodan-key")
+	provider := &Provider{}
+	require.Nil(t, provider.AddKey("shodan", "file-shodan-key"))
+	require.NotNil(t, provider.AddKey("shodan", "file-shodan-key"))
+	require.NotNil(t, provider.AddKey("unknown", "key"))
+	provider.setOrigin(OriginFile)
+	provider.LoadProviderKeysFromEnv()
+
+	require.Equal(t, []string{"file-shodan-key", "env-shodan-key"}, provider.EngineKeys("shodan"))
+	require.Equal(t, OriginFile, provider.Origin("file-shodan-key"))
+	require.Equal(t, OriginEnv, provider.Origin("env-shodan-key"))
+
+	require.Nil(t, provider.RemoveKey("shodan", "file-shodan-key"))
+	require.NotNil(t, provider.RemoveKey("shodan", "file-shodan-key"))
+	require.Equal(t, []string{"env-shodan-key"}, provider.EngineKeys("shodan"))
+
+	require.Equal(t, "user****.com:abcd****mnop", MaskKey("user@example.com:abcdefghijklmnop"))
+	require.Equal(t, "****", MaskKey("short"))
+}
+
+func TestWriteProviderConfigPreservesComments(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "provider-config.yaml")
+	require.Nil(t, os.WriteFile(location, []byte(`# uncover provider keys
+shodan:
+  - old-shodan-key # personal account
+# fofa keys use the email:key format
+fofa: []
+key_cmd:
+  censys: pass show censys
+`), 0600))
+
+	provider, err := ReadProviderConfig(location)
+	require.Nil(t, err)
+	require.Nil(t, provider.AddKey("shodan", "new-shodan-key"))
+	require.Nil(t, provider.AddKey("fofa", "user@example.com:fofa-key"))
+	require.Nil(t, provider.WriteProviderConfig(location))
+
+	data, err := os.ReadFile(location)
+	require.Nil(t, err)
+	require.Contains(t, string(data), "# uncover provider keys")
+	require.Contains(t, string(data), "# fofa keys use the email:key format")
+	require.Contains(t, string(data), "censys: pass show censys")
+
+	provider, err = ReadProviderConfig(location)
+	require.Nil(t, err)
+	require.Equal(t, []string{"old-shodan-key", "new-shodan-key"}, provider.Shodan)
+	require.Equal(t, []string{"user@example.com:fofa-key"}, provider.Fofa)
+	require.Equal(t, map[string]string{"censys": "pass show censys"}, provider.KeyCmd)
+
+	// missing configs are created
+	location = filepath.Join(t.TempDir(), "provider-config.yaml")
+	require.Nil(t, (&Provider{Quake: []string{"quake-key"}}).WriteProviderConfig(location))
+	provider, err = ReadProviderConfig(location)
+	require.Nil(t, err)
+	require.Equal(t, []string{"quake-key"}, provider.Quake)
+}
@@ -0,0 +1,181 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsNamespace is the namespace of all uncover metrics
+const MetricsNamespace = "uncover"
+
+// Error categories of the errors metric
+const (
+	ErrorCategoryTimeout      = "timeout"
+	ErrorCategoryNetwork      = "network"
+	ErrorCategoryRateLimited  = "ratelimited"
+	ErrorCategoryUnauthorized = "unauthorized"
+	ErrorCategoryHTTP         = "http"
+	ErrorCategoryDecode       = "decode"
+	ErrorCategoryEngine       = "engine"
+)
+
+// Metrics contains the prometheus collectors of sessions and agents.
+// All recording methods are no-ops on a nil instance.
+type Metrics struct {
+	// Registry contains all uncover collectors and can be gathered
+	// or served by library callers
+	Registry *prometheus.Registry
+
+	requests      *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	retries       *prometheus.CounterVec
+	rateLimitWait *prometheus.HistogramVec
+	results       *prometheus.CounterVec
+	errors        *prometheus.CounterVec
+	cacheHits     *prometheus.CounterVec
+}
+
+// NewMetrics creates the uncover collectors in a new registry
+func NewMetrics() *Metrics {
+	metrics := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "requests_total",
+			Help:      "Number of http requests sent to an engine by status code.",
+		}, []string{"engine", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of http requests sent to an engine including retries.",
+			Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"engine"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "request_retries_total",
+			Help:      "Number of retried http requests of an engine.",
+		}, []string{"engine"}),
+		rateLimitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Name:      "ratelimit_wait_seconds",
+			Help:      "Time requests of an engine waited on the rate limit.",
+			Buckets:   []float64{.01, .1, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"engine"}),
+		results: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "results_total",
+			Help:      "Number of results emitted by an engine.",
+		}, []string{"engine"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "errors_total",
+			Help:      "Number of errors of an engine by category.",
+		}, []string{"engine", "category"}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Name:      "cache_hits_total",
+			Help:      "Number of results of an engine found in the deduplication cache.",
+		}, []string{"engine"}),
+	}
+	metrics.Registry.MustRegister(
+		metrics.requests,
+		metrics.latency,
+		metrics.retries,
+		metrics.rateLimitWait,
+		metrics.results,
+		metrics.errors,
+		metrics.cacheHits,
+	)
+	return metrics
+}
+
+// Handler returns an http handler exposing the registry in prometheus
+// and openmetrics text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// ObserveRequest records an http request sent to an engine
+func (m *Metrics) ObserveRequest(engine string, resp *http.Response, retries int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	code := "error"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	m.requests.WithLabelValues(engine, code).Inc()
+	m.latency.WithLabelValues(engine).Observe(duration.Seconds())
+	if retries > 0 {
+		m.retries.WithLabelValues(engine).Add(float64(retries))
+	}
+}
+
+// ObserveRateLimitWait records the time a request of an engine waited on the rate limit
+func (m *Metrics) ObserveRateLimitWait(engine string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.rateLimitWait.WithLabelValues(engine).Observe(duration.Seconds())
+}
+
+// AddResult records a result or an error emitted by an engine
+func (m *Metrics) AddResult(engine string, result Result) {
+	if m == nil {
+		return
+	}
+	if result.Error != nil {
+		m.AddError(engine, result.Error)
+		return
+	}
+	m.results.WithLabelValues(engine).Inc()
+}
+
+// AddError records an error of an engine under its category
+func (m *Metrics) AddError(engine string, err error) {
+	if m == nil {
+		return
+	}
+	m.errors.WithLabelValues(engine, ErrorCategory(err)).Inc()
+}
+
+// AddCacheHit records a result of an engine found in the deduplication cache
+func (m *Metrics) AddCacheHit(engine string) {
+	if m == nil {
+		return
+	}
+	m.cacheHits.WithLabelValues(engine).Inc()
+}
+
+// ErrorCategory returns the metrics category of an error
+func ErrorCategory(err error) string {
+	var statusErr *StatusCodeError
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &statusErr):
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return ErrorCategoryRateLimited
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ErrorCategoryUnauthorized
+		}
+		return ErrorCategoryHTTP
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorCategoryTimeout
+	case netErr != nil:
+		return ErrorCategoryNetwork
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrorCategoryDecode
+	}
+	return ErrorCategoryEngine
+}
@@ -0,0 +1,62 @@
+package sources
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	session, err := NewSession(&Keys{}, 0, 5, 0, []string{"shodan"}, time.Second, "")
+	require.Nil(t, err)
+	metrics := NewMetrics()
+	session.Metrics = metrics
+
+	var requestErr error
+	for _, path := range []string{"/", "/limited"} {
+		req, err := retryablehttp.NewRequest(http.MethodGet, ts.URL+path, nil)
+		require.Nil(t, err)
+		_, requestErr = session.Do(req, "shodan")
+	}
+	require.Equal(t, ErrorCategoryRateLimited, ErrorCategory(requestErr))
+
+	metrics.AddResult("shodan", Result{Source: "shodan", IP: "127.0.0.1"})
+	metrics.AddResult("shodan", Result{Source: "shodan", Error: requestErr})
+	metrics.AddResult("shodan", Result{Source: "shodan", Error: errors.New("failed")})
+	metrics.AddCacheHit("shodan")
+
+	expected := `
+# HELP uncover_requests_total Number of http requests sent to an engine by status code.
+# TYPE uncover_requests_total counter
+uncover_requests_total{code="200",engine="shodan"} 1
+uncover_requests_total{code="429",engine="shodan"} 1
+# HELP uncover_results_total Number of results emitted by an engine.
+# TYPE uncover_results_total counter
+uncover_results_total{engine="shodan"} 1
+# HELP uncover_errors_total Number of errors of an engine by category.
+# TYPE uncover_errors_total counter
+uncover_errors_total{category="engine",engine="shodan"} 1
+uncover_errors_total{category="ratelimited",engine="shodan"} 1
+# HELP uncover_cache_hits_total Number of results of an engine found in the deduplication cache.
+# TYPE uncover_cache_hits_total counter
+uncover_cache_hits_total{engine="shodan"} 1
+`
+	err = testutil.GatherAndCompare(metrics.Registry, strings.NewReader(expected),
+		"uncover_requests_total", "uncover_results_total", "uncover_errors_total", "uncover_cache_hits_total")
+	require.Nil(t, err)
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.latency, "uncover_request_duration_seconds"))
+}
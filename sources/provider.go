@@ -1,10 +1,13 @@
 package sources
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/projectdiscovery/gologger"
@@ -12,6 +15,7 @@ import (
 	fileutil "github.com/projectdiscovery/utils/file"
 	folderutil "github.com/projectdiscovery/utils/folder"
 	"github.com/projectdiscovery/utils/generic"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -38,18 +42,107 @@ type Provider struct {
 	Driftnet   []string `yaml:"driftnet"`
 	GreyNoise  []string `yaml:"greynoise"`
 	NerdyData  []string `yaml:"nerdydata"`
+
+	// KeyCmd maps provider names to commands printing their key (e.g. pass show shodan)
+	KeyCmd map[string]string `yaml:"key_cmd,omitempty"`
+	// KeyFile maps provider names to files containing their keys, one per line
+	KeyFile map[string]string `yaml:"key_file,omitempty"`
+	// Keystore is the location of an encrypted keystore whose passphrase
+	// is read from the UNCOVER_KEYSTORE_PASSPHRASE env variable
+	Keystore string `yaml:"keystore,omitempty"`
+
+	// origins maps keys to the source they were loaded from
+	origins map[string]string
 }
 
-// NewProvider loads provider keys from default location and env variables
-func NewProvider() *Provider {
-	p := &Provider{}
-	if err := p.LoadProviderConfig(DefaultProviderConfigLocation); err != nil {
+// Origins of provider keys
+const (
+	OriginFile     = "file"
+	OriginEnv      = "env"
+	OriginKeyCmd   = "key_cmd"
+	OriginKeyFile  = "key_file"
+	OriginKeystore = "keystore"
+	OriginMemory   = "memory"
+)
+
+// providerOptions contains the sources provider keys are loaded from
+type providerOptions struct {
+	location    string
+	requireFile bool
+	env         bool
+	keys        map[string][]string
+}
+
+// ProviderOption configures the sources of provider keys
+type ProviderOption func(*providerOptions)
+
+// WithProviderConfig loads provider keys from the config file at given location,
+// the file must exist
+func WithProviderConfig(location string) ProviderOption {
+	return func(options *providerOptions) {
+		options.location = location
+		options.requireFile = true
+	}
+}
+
+// WithEnvOnly loads provider keys from env variables only
+func WithEnvOnly() ProviderOption {
+	return func(options *providerOptions) {
+		options.location = ""
+		options.env = true
+	}
+}
+
+// WithProviderKeys uses the given in memory keys by provider name (e.g. shodan),
+// provider config file and env variables are ignored
+func WithProviderKeys(keys map[string][]string) ProviderOption {
+	return func(options *providerOptions) {
+		options.location = ""
+		options.env = false
+		options.keys = keys
+	}
+}
+
+// NewProvider loads provider keys from the default location if it exists
+// and env variables unless configured otherwise by opts, load errors are logged
+func NewProvider(opts ...ProviderOption) *Provider {
+	p, err := LoadProvider(opts...)
+	if err != nil {
 		gologger.Error().Msgf("failed to load provider keys got %v", err)
 	}
-	p.LoadProviderKeysFromEnv()
 	return p
 }
 
+// LoadProvider loads provider keys from the default location if it exists
+// and env variables unless configured otherwise by opts, the returned provider
+// holds all keys that could be loaded even when an error is returned
+func LoadProvider(opts ...ProviderOption) (*Provider, error) {
+	options := &providerOptions{location: DefaultProviderConfigLocation, env: true}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	p := &Provider{}
+	var errs []error
+	if options.location != "" && (options.requireFile || fileutil.FileExists(options.location)) {
+		if err := p.LoadProviderConfig(options.location); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for name, keys := range options.keys {
+		for _, key := range keys {
+			if err := p.AddKey(name, key); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	p.setOrigin(OriginMemory)
+	if options.env {
+		p.LoadProviderKeysFromEnv()
+	}
+	return p, errors.Join(errs...)
+}
+
 func (provider *Provider) GetKeys() Keys {
 	keys := Keys{}
 
@@ -131,15 +224,145 @@ func (provider *Provider) GetKeys() Keys {
 	return keys
 }
 
-// LoadProvidersFrom loads provider config from given location
+// LoadProviderConfig loads provider config from given location
 func (provider *Provider) LoadProviderConfig(location string) error {
 	if !fileutil.FileExists(location) {
-		//create provider config file if it doesn't exist
-		if err := fileutil.Marshal(fileutil.YAML, []byte(location), Provider{}); err != nil {
-			return errorutil.NewWithTag("uncover", "couldn't write provider config file(%s): %s\n", location, err)
+		return errorutil.NewWithTag("uncover", "provider config file %s does not exist", location)
+	}
+	if err := fileutil.Unmarshal(fileutil.YAML, []byte(location), provider); err != nil {
+		return err
+	}
+	provider.setOrigin(OriginFile)
+	return provider.loadKeySources()
+}
+
+// ReadProviderConfig reads the provider config at given location as is,
+// without resolving its key sources
+func ReadProviderConfig(location string) (*Provider, error) {
+	provider := &Provider{}
+	if !fileutil.FileExists(location) {
+		return provider, nil
+	}
+	if err := fileutil.Unmarshal(fileutil.YAML, []byte(location), provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// WriteProviderConfig writes the provider keys to the config at given location,
+// only the key lists of an existing config are replaced so its comments,
+// layout and other settings are preserved
+func (provider *Provider) WriteProviderConfig(location string) error {
+	document := &yaml.Node{}
+	data, err := os.ReadFile(location)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := yaml.Unmarshal(data, document); err != nil {
+		return err
+	}
+	if len(document.Content) == 0 {
+		document = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errorutil.NewWithTag("uncover", "provider config %s is not a mapping", location)
+	}
+	for _, name := range provider.Names() {
+		keys := provider.EngineKeys(name)
+		index := mappingIndex(root, name)
+		if index < 0 && len(keys) == 0 {
+			continue
+		}
+		value := &yaml.Node{}
+		if err := value.Encode(keys); err != nil {
+			return err
+		}
+		if index >= 0 {
+			existing := root.Content[index+1]
+			value.HeadComment, value.LineComment, value.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+			root.Content[index+1] = value
+			continue
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(location, buffer.Bytes(), 0600)
+}
+
+// mappingIndex returns the index of the key node with given name in the mapping or -1
+func mappingIndex(mapping *yaml.Node, name string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// EngineKeys returns the keys of the provider with given name
+func (provider *Provider) EngineKeys(name string) []string {
+	if keys := provider.keys(name); keys != nil {
+		return *keys
+	}
+	return nil
+}
+
+// AddKey adds a key to the provider with given name
+func (provider *Provider) AddKey(name, key string) error {
+	keys := provider.keys(name)
+	switch {
+	case keys == nil:
+		return errorutil.NewWithTag("uncover", "unknown provider %s", name)
+	case key == "":
+		return errorutil.NewWithTag("uncover", "key cannot be empty")
+	case slices.Contains(*keys, key):
+		return errorutil.NewWithTag("uncover", "key already configured for %s", name)
+	}
+	*keys = append(*keys, key)
+	return nil
+}
+
+// RemoveKey removes a key from the provider with given name
+func (provider *Provider) RemoveKey(name, key string) error {
+	keys := provider.keys(name)
+	if keys == nil {
+		return errorutil.NewWithTag("uncover", "unknown provider %s", name)
+	}
+	index := slices.Index(*keys, key)
+	if index < 0 {
+		return errorutil.NewWithTag("uncover", "key not found for %s", name)
+	}
+	*keys = slices.Delete(*keys, index, index+1)
+	return nil
+}
+
+// Origin returns the source a key was loaded from
+func (provider *Provider) Origin(key string) string {
+	return provider.origins[key]
+}
+
+// setOrigin records the origin of all keys without a known origin
+func (provider *Provider) setOrigin(origin string) {
+	if provider.origins == nil {
+		provider.origins = make(map[string]string)
+	}
+	for _, name := range provider.Names() {
+		for _, key := range *provider.keys(name) {
+			if _, ok := provider.origins[key]; !ok {
+				provider.origins[key] = origin
+			}
 		}
 	}
-	return fileutil.Unmarshal(fileutil.YAML, []byte(location), provider)
 }
 
 // LoadProviderKeysFromEnv loads provider keys from env variables
@@ -178,6 +401,22 @@ func (provider *Provider) LoadProviderKeysFromEnv() {
 	provider.Onyphe = appendIfExists(provider.Onyphe, "ONYPHE_API_KEY")
 	provider.GreyNoise = appendIfExists(provider.GreyNoise, "GREYNOISE_API_KEY")
 	provider.NerdyData = appendIfExists(provider.NerdyData, "NERDYDATA_API_KEY")
+	provider.setOrigin(OriginEnv)
+}
+
+// Secrets returns all configured keys of all providers including
+// the parts of composite keys (e.g. email:key)
+func (provider *Provider) Secrets() []string {
+	var secrets []string
+	for _, name := range provider.Names() {
+		for _, key := range provider.EngineKeys(name) {
+			secrets = append(secrets, key)
+			if parts := strings.Split(key, ":"); len(parts) > 1 {
+				secrets = append(secrets, parts...)
+			}
+		}
+	}
+	return secrets
 }
 
 // HasKeys returns true if at least one agent/source has keys
@@ -202,18 +441,3 @@ func (provider *Provider) HasKeys() bool {
 		len(provider.NerdyData) > 0,
 	)
 }
-
-func init() {
-	// check if config dir exists
-	if !fileutil.FolderExists(UncoverConfigDir) {
-		if err := fileutil.CreateFolder(UncoverConfigDir); err != nil {
-			gologger.Warning().Msgf("couldn't create uncover config dir: %s\n", err)
-		}
-	}
-	// create default provider file if it doesn't exist
-	if !fileutil.FileExists(DefaultProviderConfigLocation) {
-		if err := fileutil.Marshal(fileutil.YAML, []byte(DefaultProviderConfigLocation), Provider{}); err != nil {
-			gologger.Warning().Msgf("couldn't write provider default file: %s\n", err)
-		}
-	}
-}
@@ -0,0 +1,49 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProvider(t *testing.T) {
+	dir := t.TempDir()
+	location := DefaultProviderConfigLocation
+	DefaultProviderConfigLocation = filepath.Join(dir, "missing", "provider-config.yaml")
+	defer func() { DefaultProviderConfigLocation = location }()
+	t.Setenv("SHODAN_API_KEY", "env-key")
+
+	// a missing default config file is ignored and never created
+	provider, err := LoadProvider()
+	require.Nil(t, err)
+	require.Equal(t, []string{"env-key"}, provider.Shodan)
+	require.NoFileExists(t, DefaultProviderConfigLocation)
+
+	config := filepath.Join(dir, "provider-config.yaml")
+	require.Nil(t, os.WriteFile(config, []byte("shodan:\n  - file-key\n"), 0600))
+	provider, err = LoadProvider(WithProviderConfig(config))
+	require.Nil(t, err)
+	require.Equal(t, []string{"file-key", "env-key"}, provider.Shodan)
+	require.Equal(t, OriginFile, provider.Origin("file-key"))
+	require.Equal(t, OriginEnv, provider.Origin("env-key"))
+
+	_, err = LoadProvider(WithProviderConfig(filepath.Join(dir, "custom.yaml")))
+	require.ErrorContains(t, err, "does not exist")
+	require.NoFileExists(t, filepath.Join(dir, "custom.yaml"))
+
+	DefaultProviderConfigLocation = config
+	provider, err = LoadProvider(WithEnvOnly())
+	require.Nil(t, err)
+	require.Equal(t, []string{"env-key"}, provider.Shodan)
+
+	provider, err = LoadProvider(WithProviderKeys(map[string][]string{"shodan": {"memory-key"}, "fofa": {"user@example.com:fofa-key"}}))
+	require.Nil(t, err)
+	require.Equal(t, []string{"memory-key"}, provider.Shodan)
+	require.Equal(t, []string{"user@example.com:fofa-key"}, provider.Fofa)
+	require.Equal(t, OriginMemory, provider.Origin("memory-key"))
+
+	_, err = LoadProvider(WithProviderKeys(map[string][]string{"unknown": {"key"}}))
+	require.ErrorContains(t, err, "unknown provider")
+}
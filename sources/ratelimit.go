@@ -0,0 +1,70 @@
+package sources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/ratelimit"
+	errorutil "github.com/projectdiscovery/utils/errors"
+)
+
+// RateLimit is the maximum number of requests sent per duration
+type RateLimit struct {
+	MaxCount uint
+	Duration time.Duration
+}
+
+// rateLimitUnits are the short units supported by ParseRateLimit
+var rateLimitUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseRateLimit parses a rate limit in count/unit format (e.g. 2/s, 30/m, 5/10s),
+// a count without unit is per second
+func ParseRateLimit(value string) (RateLimit, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		unit = "s"
+	}
+	maxCount, err := strconv.ParseUint(strings.TrimSpace(count), 10, 0)
+	if err != nil || maxCount == 0 {
+		return RateLimit{}, errorutil.NewWithTag("uncover", "invalid rate limit %q, count must be a positive number", value)
+	}
+	unit = strings.TrimSpace(unit)
+	duration, ok := rateLimitUnits[unit]
+	if !ok {
+		if duration, err = time.ParseDuration(unit); err != nil || duration <= 0 {
+			return RateLimit{}, errorutil.NewWithTag("uncover", "invalid rate limit %q, unit must be s, m, h or a duration", value)
+		}
+	}
+	return RateLimit{MaxCount: uint(maxCount), Duration: duration}, nil
+}
+
+// IsZero returns true if no rate limit is set
+func (r RateLimit) IsZero() bool {
+	return r.MaxCount == 0
+}
+
+// Validate returns an error if the rate limit has a count but no duration
+func (r RateLimit) Validate() error {
+	if r.MaxCount > 0 && r.Duration <= 0 {
+		return errorutil.NewWithTag("uncover", "rate limit of %d requests has no duration", r.MaxCount)
+	}
+	return nil
+}
+
+func (r RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", r.MaxCount, r.Duration)
+}
+
+// options returns the limiter options of the rate limit for given key
+func (r RateLimit) options(key string) *ratelimit.Options {
+	if r.IsZero() {
+		return &ratelimit.Options{Key: key, IsUnlimited: true}
+	}
+	return &ratelimit.Options{Key: key, MaxCount: r.MaxCount, Duration: r.Duration}
+}
@@ -0,0 +1,27 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	for value, expected := range map[string]RateLimit{
+		"10":     {MaxCount: 10, Duration: time.Second},
+		"2/s":    {MaxCount: 2, Duration: time.Second},
+		"30/m":   {MaxCount: 30, Duration: time.Minute},
+		"100/h":  {MaxCount: 100, Duration: time.Hour},
+		"5/10s":  {MaxCount: 5, Duration: 10 * time.Second},
+		" 1 / s": {MaxCount: 1, Duration: time.Second},
+	} {
+		rateLimit, err := ParseRateLimit(value)
+		require.Nil(t, err, value)
+		require.Equal(t, expected, rateLimit, value)
+	}
+	for _, value := range []string{"", "0/s", "-1", "x/s", "2/d", "2/-1s"} {
+		_, err := ParseRateLimit(value)
+		require.Error(t, err, value)
+	}
+}
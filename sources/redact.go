@@ -0,0 +1,156 @@
+package sources
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in redacted text
+const Redacted = "REDACTED"
+
+// sensitiveParams are url query parameters whose values are redacted in spans
+var sensitiveParams = []string{"key", "apikey", "api_key", "api-key", "token", "access_token", "email", "secret", "password"}
+
+var (
+	credentialParamsRegex = regexp.MustCompile(`(?i)([?&](?:` + strings.Join(sensitiveParams, "|") + `)=)[^&\s"']+`)
+	userInfoRegex         = regexp.MustCompile(`://[^/@\s"']+@`)
+)
+
+// minSecretLength is the minimum length of secrets known to a redactor,
+// shorter values would scrub unrelated text
+const minSecretLength = 6
+
+// Redactor scrubs configured secrets from text and errors.
+// All methods are safe for concurrent use and on a nil redactor.
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+}
+
+// NewRedactor creates a redactor knowing given secrets
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{}
+	r.Add(secrets...)
+	return r
+}
+
+// Add adds secrets to the redactor
+func (r *Redactor) Add(secrets ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, secret := range secrets {
+		secret = strings.TrimSpace(secret)
+		if len(secret) < minSecretLength || contains(r.secrets, secret) {
+			continue
+		}
+		r.secrets = append(r.secrets, secret)
+		// keys are also sent url encoded
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			r.secrets = append(r.secrets, escaped)
+		}
+	}
+	// replace longer secrets first so overlapping secrets are fully scrubbed
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+// Redact returns text with all known secrets replaced
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, Redacted)
+	}
+	return text
+}
+
+// Error returns err with all known secrets and url credentials removed from its
+// message, the original error remains available to errors.Is and errors.As
+func (r *Redactor) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	redacted := redactURLCredentials(r.Redact(message))
+	if redacted == message {
+		return err
+	}
+	return &redactedError{message: redacted, err: err}
+}
+
+// Recover converts a panic of an agent goroutine into an error result of the
+// agent with all known secrets redacted, it must be deferred by the goroutine
+func (s *Session) Recover(source string, results chan Result) {
+	if r := recover(); r != nil {
+		err := fmt.Errorf("%s agent crashed: %v\n%s", source, r, debug.Stack())
+		results <- Result{Source: source, Error: s.Redactor.Error(err)}
+	}
+}
+
+// redactURLCredentials replaces user info and credential parameters of urls in text
+func redactURLCredentials(text string) string {
+	text = credentialParamsRegex.ReplaceAllString(text, "${1}"+Redacted)
+	return userInfoRegex.ReplaceAllString(text, "://"+Redacted+"@")
+}
+
+// RedactURL returns the url with user info and credential parameters redacted
+func RedactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid url>"
+	}
+	if parsed.User != nil {
+		parsed.User = url.User(Redacted)
+	}
+	values := parsed.Query()
+	redacted := false
+	for name := range values {
+		for _, param := range sensitiveParams {
+			if strings.EqualFold(name, param) {
+				values.Set(name, Redacted)
+				redacted = true
+			}
+		}
+	}
+	if redacted {
+		parsed.RawQuery = values.Encode()
+	}
+	return parsed.String()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedError is an error whose message has been scrubbed of secrets
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -12,8 +12,26 @@ type Result struct {
 	Port      int    `json:"port"`
 	Host      string `json:"host"`
 	Url       string `json:"url"`
-	Raw       []byte `json:"-"`
-	Error     error  `json:"-"`
+	// Service is the protocol name of the service (e.g. http, ssh) if known
+	Service string `json:"service,omitempty"`
+	// Software contains the products detected on the service
+	Software []string `json:"software,omitempty"`
+	// ASN is the autonomous system number of the ip
+	ASN int `json:"asn,omitempty"`
+	// Org is the organization owning the ip
+	Org string `json:"org,omitempty"`
+	// Country is the country code of the ip
+	Country string `json:"country,omitempty"`
+	// City is the city of the ip
+	City string `json:"city,omitempty"`
+	// Vulns contains the CVE identifiers the service is vulnerable to
+	Vulns []string `json:"vulns,omitempty"`
+	// CPEs contains the CPE identifiers of the detected software
+	CPEs []string `json:"cpes,omitempty"`
+	// Tags contains the labels of the engine (e.g. cloud, self-signed)
+	Tags  []string `json:"tags,omitempty"`
+	Raw   []byte   `json:"-"`
+	Error error    `json:"-"`
 }
 
 func (result *Result) IpPort() string {
@@ -2,7 +2,7 @@ package sources
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,6 +11,9 @@ import (
 	"github.com/projectdiscovery/ratelimit"
 	"github.com/projectdiscovery/retryablehttp-go"
 	errorutil "github.com/projectdiscovery/utils/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DefaultRateLimits of all/most of sources are hardcoded by default to improve performance
@@ -36,70 +39,192 @@ var DefaultRateLimits = map[string]*ratelimit.Options{
 	"nerdydata":  {Key: "nerdydata", MaxCount: 1, Duration: time.Second},
 }
 
+// StatusCodeError is returned for requests to an engine answered with an unexpected status code
+type StatusCodeError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *StatusCodeError) Error() string {
+	return fmt.Sprintf("unexpected status code %d received from %s", e.StatusCode, e.URL)
+}
+
 // Session handles session agent sessions
 type Session struct {
 	Keys       *Keys
 	Client     *retryablehttp.Client
 	RetryMax   int
 	RateLimits *ratelimit.MultiLimiter
+	// Stats if set collects request statistics of engines
+	Stats *Stats
+	// Metrics if set records prometheus metrics of engine requests
+	Metrics *Metrics
+	// DebugRequests logs the http requests sent to engines
+	DebugRequests bool
+	// DebugResponses logs the http responses received from engines
+	DebugResponses bool
+	// Redactor scrubs the provider keys from errors and logs of the session
+	Redactor *Redactor
+	// Recorder if set receives the redacted http interactions of engines
+	Recorder Recorder
+
+	// clients are the http clients of engines with their own http options
+	clients map[string]*retryablehttp.Client
+	ctx     context.Context
 }
 
-func NewSession(keys *Keys, retryMax, timeout, rateLimit int, engines []string, duration time.Duration, proxy string) (*Session, error) {
-	Transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
-		ResponseHeaderTimeout: time.Duration(timeout) * time.Second,
-		Proxy: func(req *http.Request) (*url.URL, error) {
-			if proxy != "" {
-				if proxyURL, err := url.Parse(proxy); err == nil {
-					return http.ProxyURL(proxyURL)(req)
-				}
-			}
-			return http.ProxyFromEnvironment(req)
-		},
+// DefaultTimeout is the http timeout in seconds of sessions without timeout
+const DefaultTimeout = 30
+
+// SessionOptions contains the configuration options of a session
+type SessionOptions struct {
+	Keys *Keys
+	// RetryMax is the number of times a failed request is retried
+	RetryMax int
+	// Timeout is the http timeout in seconds, DefaultTimeout if zero
+	Timeout int
+	// Engines are the engines whose rate limits are setup
+	Engines []string
+	// RateLimit is the rate limit of engines without default rate limit
+	// in DefaultRateLimits, requests are unlimited if zero
+	RateLimit RateLimit
+	// RateLimits overrides the rate limits of engines by name
+	RateLimits map[string]RateLimit
+	// Proxy is the http proxy to use, env proxy settings are used if empty
+	Proxy string
+	// HTTP is the http client configuration of all engines, its proxy defaults to Proxy
+	HTTP HTTPOptions
+	// EngineHTTP overrides the http client configuration of engines by name
+	EngineHTTP map[string]HTTPOptions
+}
+
+// Validate validates the session options
+func (options *SessionOptions) Validate() error {
+	switch {
+	case options.RetryMax < 0:
+		return errorutil.NewWithTag("uncover", "retries cannot be negative")
+	case options.Timeout < 0:
+		return errorutil.NewWithTag("uncover", "timeout cannot be negative")
+	}
+	if err := options.RateLimit.Validate(); err != nil {
+		return err
+	}
+	for engine, rateLimit := range options.RateLimits {
+		if err := rateLimit.Validate(); err != nil {
+			return errorutil.NewWithErr(err).Msgf("invalid rate limit of %s", engine)
+		}
+	}
+	if err := options.httpOptions("").Validate(); err != nil {
+		return err
 	}
+	for engine := range options.EngineHTTP {
+		if err := options.httpOptions(engine).Validate(); err != nil {
+			return errorutil.NewWithErr(err).Msgf("invalid http options of %s", engine)
+		}
+	}
+	return nil
+}
 
-	httpclient := &http.Client{
-		Transport: Transport,
-		Timeout:   time.Duration(timeout) * time.Second,
+// httpOptions returns the http client configuration of given engine
+func (options *SessionOptions) httpOptions(engine string) HTTPOptions {
+	defaults := options.HTTP
+	if defaults.Proxy == "" {
+		defaults.Proxy = options.Proxy
+	}
+	if engineOptions, ok := options.EngineHTTP[engine]; ok {
+		return engineOptions.Merge(defaults)
 	}
+	return defaults
+}
 
-	options := retryablehttp.Options{RetryMax: retryMax}
-	options.RetryWaitMax = time.Duration(timeout) * time.Second
-	client := retryablehttp.NewWithHTTPClient(httpclient, options)
+// rateLimit returns the limiter options of given engine
+func (options *SessionOptions) rateLimit(engine string) *ratelimit.Options {
+	if rateLimit, ok := options.RateLimits[engine]; ok && !rateLimit.IsZero() {
+		return rateLimit.options(engine)
+	}
+	if defaultRateLimit, ok := DefaultRateLimits[engine]; ok {
+		rateLimit := *defaultRateLimit
+		return &rateLimit
+	}
+	return options.RateLimit.options(engine)
+}
 
-	session := &Session{
-		Client:   client,
+func NewSession(keys *Keys, retryMax, timeout, rateLimit int, engines []string, duration time.Duration, proxy string) (*Session, error) {
+	options := &SessionOptions{
 		Keys:     keys,
 		RetryMax: retryMax,
+		Timeout:  timeout,
+		Engines:  engines,
+		Proxy:    proxy,
+	}
+	if rateLimit > 0 {
+		options.RateLimit = RateLimit{MaxCount: uint(rateLimit), Duration: duration}
 	}
+	return NewSessionWithOptions(options)
+}
 
-	var defaultRatelimit *ratelimit.Options
-	switch {
-	case rateLimit > 0:
-		defaultRatelimit = &ratelimit.Options{Key: "default", MaxCount: uint(rateLimit), Duration: duration}
-	default:
-		defaultRatelimit = &ratelimit.Options{IsUnlimited: true, Key: "default"}
+// NewSessionWithOptions creates a new session with given options
+func NewSessionWithOptions(options *SessionOptions) (*Session, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(options.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = DefaultTimeout * time.Second
+	}
+
+	// engines with the same proxy and tls configuration share a transport and its connection pool
+	transports := make(map[string]*http.Transport)
+	newClient := func(httpOptions HTTPOptions) (*retryablehttp.Client, error) {
+		key := httpOptions.transportKey()
+		transport, ok := transports[key]
+		if !ok {
+			var err error
+			if transport, err = httpOptions.newTransport(timeout); err != nil {
+				return nil, err
+			}
+			transports[key] = transport
+		}
+		httpclient := &http.Client{
+			Transport: &headerTransport{base: transport, headers: httpOptions.Headers, userAgent: httpOptions.UserAgent},
+			Timeout:   timeout,
+		}
+		clientOptions := retryablehttp.Options{RetryMax: options.RetryMax}
+		clientOptions.RetryWaitMax = timeout
+		return retryablehttp.NewWithHTTPClient(httpclient, clientOptions), nil
+	}
+
+	client, err := newClient(options.httpOptions(""))
+	if err != nil {
+		return nil, err
+	}
+	clients := make(map[string]*retryablehttp.Client)
+	for engine := range options.EngineHTTP {
+		if clients[engine], err = newClient(options.httpOptions(engine)); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := options.Keys
+	if keys == nil {
+		keys = &Keys{}
+	}
+	session := &Session{
+		Client:   client,
+		Keys:     keys,
+		RetryMax: options.RetryMax,
+		Redactor: NewRedactor(keys.Secrets()...),
+		clients:  clients,
 	}
 
-	var err error
-	session.RateLimits, err = ratelimit.NewMultiLimiter(context.Background(), defaultRatelimit)
+	session.RateLimits, err = ratelimit.NewMultiLimiter(context.Background(), options.RateLimit.options("default"))
 	if err != nil {
 		return nil, err
 	}
 
 	// setup ratelimit of all engines
-	for _, engine := range engines {
-		rateLimitOpts := DefaultRateLimits[engine]
-		if rateLimitOpts == nil {
-			// fallback to using default ratelimit
-			rateLimitOpts = defaultRatelimit
-			rateLimitOpts.Key = engine
-		}
-		if err = session.RateLimits.Add(rateLimitOpts); err != nil {
+	for _, engine := range options.Engines {
+		if err = session.RateLimits.Add(options.rateLimit(engine)); err != nil {
 			return nil, errorutil.NewWithErr(err).Msgf("failed to setup ratelimit of %v got %v", engine, err)
 		}
 	}
@@ -107,20 +232,118 @@ func NewSession(keys *Keys, retryMax, timeout, rateLimit int, engines []string,
 	return session, nil
 }
 
+// WithContext returns a shallow copy of the session whose requests use given context,
+// agents querying the copy propagate its cancellation and tracing span
+func (s *Session) WithContext(ctx context.Context) *Session {
+	session := *s
+	session.ctx = ctx
+	return &session
+}
+
+// WithStats returns a shallow copy of the session recording its requests in given statistics
+func (s *Session) WithStats(stats *Stats) *Session {
+	session := *s
+	session.Stats = stats
+	return &session
+}
+
+// EngineClient returns the http client of given engine
+func (s *Session) EngineClient(engine string) *retryablehttp.Client {
+	if client, ok := s.clients[engine]; ok {
+		return client
+	}
+	return s.Client
+}
+
+// Context returns the context of the session
+func (s *Session) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
 func (s *Session) Do(request *retryablehttp.Request, source string) (*http.Response, error) {
+	ctx := s.Context()
+	if request.Context() != context.Background() {
+		ctx = request.Context()
+	}
+	tracer := Tracer()
+
+	_, waitSpan := tracer.Start(ctx, "ratelimit.wait", trace.WithAttributes(attribute.String("uncover.engine", source)))
+	waitDone := s.Stats.StartRateLimitWait(source)
+	waitStart := time.Now()
 	err := s.RateLimits.Take(source)
+	waitDone()
+	s.Metrics.ObserveRateLimitWait(source, time.Since(waitStart))
+	waitSpan.End()
 	if err != nil {
 		return nil, err
 	}
+
+	ctx, span := tracer.Start(ctx, "http.request", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("uncover.engine", source),
+		attribute.String("http.request.method", request.Method),
+		attribute.String("url.full", s.Redactor.Redact(RedactURL(request.String()))),
+	))
+	defer span.End()
+	request = request.WithContext(ctx)
+
 	// close request connection (does not reuse connections)
 	request.Close = true
-	resp, err := s.Client.Do(request)
+	if s.DebugRequests {
+		s.debugRequest(request, source)
+	}
+	start := time.Now()
+	resp, err := s.EngineClient(source).Do(request)
+	s.Stats.AddRequest(source, request.Metrics.Retries, err != nil || resp.StatusCode != http.StatusOK)
+	s.Metrics.ObserveRequest(source, resp, request.Metrics.Retries, time.Since(start))
+	span.SetAttributes(
+		attribute.Int("http.request.resend_count", request.Metrics.Retries),
+		attribute.Int("uncover.attempts", request.Metrics.Retries+1),
+	)
 	if err != nil {
+		err = s.Redactor.Error(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if s.DebugResponses {
+		s.debugResponse(resp, source)
+	}
+	if s.Recorder != nil {
+		s.record(request, resp, source)
+	}
 	if resp.StatusCode != http.StatusOK {
-		requestURL, _ := url.QueryUnescape(request.String())
-		return resp, fmt.Errorf("unexpected status code %d received from %s", resp.StatusCode, requestURL)
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		requestURL, _ := url.QueryUnescape(RedactURL(request.String()))
+		return resp, &StatusCodeError{StatusCode: resp.StatusCode, URL: s.Redactor.Redact(requestURL)}
 	}
 	return resp, nil
 }
+
+// Transport returns a round tripper sending the requests of the engine through Do,
+// it lets sdk clients of engines share the rate limit, debug and metrics of the session
+func (s *Session) Transport(engine string) http.RoundTripper {
+	return &sessionTransport{session: s, engine: engine}
+}
+
+type sessionTransport struct {
+	session *Session
+	engine  string
+}
+
+func (transport *sessionTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	retryableRequest, err := retryablehttp.FromRequest(request.Clone(request.Context()))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := transport.session.Do(retryableRequest, transport.engine)
+	var statusCodeErr *StatusCodeError
+	if resp != nil && errors.As(err, &statusCodeErr) {
+		// unexpected status codes are left to the sdk client
+		return resp, nil
+	}
+	return resp, err
+}
@@ -3,6 +3,7 @@ package sources
 import (
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -28,3 +29,40 @@ func TestSessionRetry(t *testing.T) {
 	require.ErrorContains(t, err, "giving up after 6 attempts")
 	require.Nil(t, resp)
 }
+
+func TestSessionOptions(t *testing.T) {
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		// abort the connection so the request fails and is retried
+		panic(http.ErrAbortHandler)
+	}))
+	defer ts.Close()
+
+	session, err := NewSessionWithOptions(&SessionOptions{
+		RetryMax:   2,
+		Engines:    []string{"shodan", "fofa", "custom"},
+		RateLimit:  RateLimit{MaxCount: 7, Duration: time.Second},
+		RateLimits: map[string]RateLimit{"shodan": {MaxCount: 2, Duration: time.Second}},
+	})
+	require.Nil(t, err)
+	// zero timeout falls back to the default timeout
+	require.Equal(t, DefaultTimeout*time.Second, session.Client.HTTPClient.Timeout)
+
+	for engine, expected := range map[string]uint{"shodan": 2, "fofa": DefaultRateLimits["fofa"].MaxCount, "custom": 7} {
+		limit, err := session.RateLimits.GetLimit(engine)
+		require.Nil(t, err)
+		require.Equal(t, expected, limit, engine)
+	}
+
+	req, err := retryablehttp.NewRequest(http.MethodGet, ts.URL, nil)
+	require.Nil(t, err)
+	_, err = session.Do(req, "custom")
+	require.Error(t, err)
+	require.Equal(t, int32(3), attempts.Load())
+
+	_, err = NewSessionWithOptions(&SessionOptions{Timeout: -1})
+	require.ErrorContains(t, err, "timeout cannot be negative")
+	_, err = NewSessionWithOptions(&SessionOptions{RetryMax: -1})
+	require.ErrorContains(t, err, "retries cannot be negative")
+}
@@ -0,0 +1,309 @@
+package sources
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxErrorMessages is the maximum number of distinct error messages kept per engine
+const maxErrorMessages = 10
+
+// EngineStats contains the statistics of a single engine
+type EngineStats struct {
+	Engine         string        `json:"engine"`
+	Results        int           `json:"results"`
+	Duplicates     int           `json:"duplicates"`
+	Errors         int           `json:"errors"`
+	ErrorMessages  []string      `json:"error_messages,omitempty"`
+	Requests       int           `json:"requests"`
+	FailedRequests int           `json:"failed_requests"`
+	Retries        int           `json:"retries"`
+	RateLimitWait  time.Duration `json:"rate_limit_wait"`
+	Duration       time.Duration `json:"duration"`
+	// Total is the sum of result totals reported by the engine for its queries
+	Total int `json:"total,omitempty"`
+	// QuotaUsed is the sum of the quota consumed by the queries of engines reporting it
+	QuotaUsed int `json:"quota_used,omitempty"`
+	// QuotaRemaining is the last remaining quota reported by the engine
+	QuotaRemaining *int `json:"quota_remaining,omitempty"`
+	// RateLimitWaiting is the number of requests currently waiting on the rate limit
+	RateLimitWaiting int `json:"-"`
+
+	started time.Time
+	running int
+}
+
+// QueryStats contains the statistics of a query on a single engine
+type QueryStats struct {
+	Engine   string        `json:"engine"`
+	Query    string        `json:"query"`
+	Results  int           `json:"results"`
+	Errors   int           `json:"errors"`
+	Duration time.Duration `json:"duration"`
+	// Total is the number of results the engine reported for the query
+	Total    int  `json:"total,omitempty"`
+	Finished bool `json:"finished"`
+
+	started time.Time
+}
+
+// StatsReport is a point in time snapshot of collected statistics
+type StatsReport struct {
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	Results    int           `json:"results"`
+	Duplicates int           `json:"duplicates"`
+	Errors     int           `json:"errors"`
+	Requests   int           `json:"requests"`
+	Retries    int           `json:"retries"`
+	Engines    []EngineStats `json:"engines"`
+	Queries    []QueryStats  `json:"queries"`
+}
+
+type queryKey struct {
+	engine string
+	query  string
+}
+
+// Stats collects statistics of requests, results and errors per engine and query.
+// All recording methods are no-ops on a nil collector.
+type Stats struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	engines   map[string]*EngineStats
+	queries   map[queryKey]*QueryStats
+}
+
+// NewStats creates a new statistics collector
+func NewStats() *Stats {
+	return &Stats{
+		startedAt: time.Now(),
+		engines:   make(map[string]*EngineStats),
+		queries:   make(map[queryKey]*QueryStats),
+	}
+}
+
+func (s *Stats) engine(name string) *EngineStats {
+	engine, ok := s.engines[name]
+	if !ok {
+		engine = &EngineStats{Engine: name}
+		s.engines[name] = engine
+	}
+	return engine
+}
+
+// QueryStarted marks the start of a query on an engine
+func (s *Stats) QueryStarted(engine, query string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	engineStats := s.engine(engine)
+	if engineStats.started.IsZero() {
+		engineStats.started = now
+	}
+	engineStats.running++
+	key := queryKey{engine: engine, query: query}
+	if _, ok := s.queries[key]; !ok {
+		s.queries[key] = &QueryStats{Engine: engine, Query: query, started: now}
+	}
+}
+
+// QueryFinished marks the end of a query on an engine
+func (s *Stats) QueryFinished(engine, query string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	engineStats := s.engine(engine)
+	engineStats.running--
+	if !engineStats.started.IsZero() {
+		engineStats.Duration = now.Sub(engineStats.started)
+	}
+	if queryStats, ok := s.queries[queryKey{engine: engine, query: query}]; ok {
+		queryStats.Duration = now.Sub(queryStats.started)
+		queryStats.Finished = true
+	}
+}
+
+// SetTotal records the total number of results an engine reported for a query
+func (s *Stats) SetTotal(engine, query string, total int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engineStats := s.engine(engine)
+	key := queryKey{engine: engine, query: query}
+	queryStats, ok := s.queries[key]
+	if !ok {
+		queryStats = &QueryStats{Engine: engine, Query: query, started: time.Now()}
+		s.queries[key] = queryStats
+	}
+	engineStats.Total += total - queryStats.Total
+	queryStats.Total = total
+}
+
+// AddQuota records the quota consumed by a request of an engine
+// and the remaining quota reported with it
+func (s *Stats) AddQuota(engine string, used, remaining int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engineStats := s.engine(engine)
+	engineStats.QuotaUsed += used
+	engineStats.QuotaRemaining = &remaining
+}
+
+// AddResult records a result or an error returned by an engine for a query
+func (s *Stats) AddResult(engine, query string, result Result) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engineStats := s.engine(engine)
+	queryStats := s.queries[queryKey{engine: engine, query: query}]
+	if result.Error != nil {
+		engineStats.Errors++
+		if queryStats != nil {
+			queryStats.Errors++
+		}
+		addErrorMessage(engineStats, result.Error.Error())
+		return
+	}
+	engineStats.Results++
+	if queryStats != nil {
+		queryStats.Results++
+	}
+}
+
+// AddError records an error of an engine that is not part of its results
+func (s *Stats) AddError(engine string, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engineStats := s.engine(engine)
+	engineStats.Errors++
+	addErrorMessage(engineStats, err.Error())
+}
+
+func addErrorMessage(engineStats *EngineStats, message string) {
+	for _, v := range engineStats.ErrorMessages {
+		if v == message {
+			return
+		}
+	}
+	if len(engineStats.ErrorMessages) < maxErrorMessages {
+		engineStats.ErrorMessages = append(engineStats.ErrorMessages, message)
+	}
+}
+
+// AddDuplicate records a result of an engine that was dropped as duplicate
+func (s *Stats) AddDuplicate(engine string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.engine(engine).Duplicates++
+}
+
+// AddRequest records an http request made to an engine
+func (s *Stats) AddRequest(engine string, retries int, failed bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engineStats := s.engine(engine)
+	engineStats.Requests++
+	engineStats.Retries += retries
+	if failed {
+		engineStats.FailedRequests++
+	}
+}
+
+// StartRateLimitWait marks a request of an engine as waiting on the rate limit
+// and returns a function recording the time spent once the wait is over
+func (s *Stats) StartRateLimitWait(engine string) func() {
+	if s == nil {
+		return func() {}
+	}
+	s.mu.Lock()
+	s.engine(engine).RateLimitWaiting++
+	s.mu.Unlock()
+
+	start := time.Now()
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		engineStats := s.engine(engine)
+		engineStats.RateLimitWaiting--
+		engineStats.RateLimitWait += time.Since(start)
+	}
+}
+
+// Report returns a snapshot of the collected statistics
+func (s *Stats) Report() *StatsReport {
+	if s == nil {
+		return &StatsReport{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &StatsReport{
+		StartedAt: s.startedAt,
+		Duration:  time.Since(s.startedAt),
+		Engines:   make([]EngineStats, 0, len(s.engines)),
+		Queries:   make([]QueryStats, 0, len(s.queries)),
+	}
+	for _, engine := range s.engines {
+		engineStats := *engine
+		if engine.running > 0 {
+			engineStats.Duration = time.Since(engine.started)
+		}
+		engineStats.ErrorMessages = append([]string(nil), engine.ErrorMessages...)
+		report.Engines = append(report.Engines, engineStats)
+		report.Results += engine.Results
+		report.Duplicates += engine.Duplicates
+		report.Errors += engine.Errors
+		report.Requests += engine.Requests
+		report.Retries += engine.Retries
+	}
+	for _, query := range s.queries {
+		queryStats := *query
+		if !query.Finished && !query.started.IsZero() {
+			queryStats.Duration = time.Since(query.started)
+		}
+		report.Queries = append(report.Queries, queryStats)
+	}
+	sort.Slice(report.Engines, func(i, j int) bool {
+		return report.Engines[i].Engine < report.Engines[j].Engine
+	})
+	sort.Slice(report.Queries, func(i, j int) bool {
+		if report.Queries[i].Engine != report.Queries[j].Engine {
+			return report.Queries[i].Engine < report.Queries[j].Engine
+		}
+		return report.Queries[i].Query < report.Queries[j].Query
+	})
+	return report
+}
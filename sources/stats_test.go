@@ -0,0 +1,68 @@
+package sources
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	session, err := NewSession(&Keys{}, 0, 5, 0, []string{"shodan"}, time.Second, "")
+	require.Nil(t, err)
+	session.Stats = NewStats()
+
+	for _, path := range []string{"/", "/missing"} {
+		req, err := retryablehttp.NewRequest(http.MethodGet, ts.URL+path, nil)
+		require.Nil(t, err)
+		_, _ = session.Do(req, "shodan")
+	}
+
+	session.Stats.QueryStarted("shodan", "test")
+	session.Stats.AddResult("shodan", "test", Result{Source: "shodan", IP: "127.0.0.1"})
+	session.Stats.AddResult("shodan", "test", Result{Source: "shodan", Error: errors.New("failed")})
+	session.Stats.AddDuplicate("shodan")
+	session.Stats.QueryFinished("shodan", "test")
+
+	report := session.Stats.Report()
+	require.Len(t, report.Engines, 1)
+	engine := report.Engines[0]
+	require.Equal(t, 2, engine.Requests)
+	require.Equal(t, 1, engine.FailedRequests)
+	require.Equal(t, 1, engine.Results)
+	require.Equal(t, 1, engine.Duplicates)
+	require.Equal(t, []string{"failed"}, engine.ErrorMessages)
+	require.Len(t, report.Queries, 1)
+	require.Equal(t, 1, report.Queries[0].Errors)
+}
+
+func TestStatsQuota(t *testing.T) {
+	stats := NewStats()
+	stats.AddQuota("hunter", 100, 400)
+	stats.AddQuota("hunter", 50, 350)
+	stats.AddRequest("shodan", 0, false)
+
+	report := stats.Report()
+	require.Len(t, report.Engines, 2)
+	require.Equal(t, 150, report.Engines[0].QuotaUsed)
+	require.NotNil(t, report.Engines[0].QuotaRemaining)
+	require.Equal(t, 350, *report.Engines[0].QuotaRemaining)
+	require.Nil(t, report.Engines[1].QuotaRemaining)
+}
+
+func TestStatsNilReport(t *testing.T) {
+	var stats *Stats
+	stats.AddRequest("shodan", 0, false)
+	require.NotNil(t, stats.Report())
+}
@@ -0,0 +1,15 @@
+package sources
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the opentelemetry tracer of uncover spans
+const TracerName = "github.com/projectdiscovery/uncover"
+
+// Tracer returns the uncover tracer of the global opentelemetry tracer provider,
+// spans are not recorded unless a tracer provider is configured
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
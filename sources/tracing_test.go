@@ -0,0 +1,46 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSessionSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	session, err := NewSession(&Keys{}, 0, 5, 0, []string{"shodan"}, time.Second, "")
+	require.Nil(t, err)
+	ctx, parent := Tracer().Start(context.Background(), "uncover.query")
+	req, err := retryablehttp.NewRequest(http.MethodGet, ts.URL+"/search?key=secret", nil)
+	require.Nil(t, err)
+	_, err = session.WithContext(ctx).Do(req, "shodan")
+	require.Nil(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	require.Equal(t, "ratelimit.wait", spans[0].Name())
+	request := spans[1]
+	require.Equal(t, "http.request", request.Name())
+	require.Equal(t, parent.SpanContext().SpanID(), request.Parent().SpanID())
+	require.Contains(t, request.Attributes(), attribute.String("url.full", ts.URL+"/search?key=REDACTED"))
+	require.Contains(t, request.Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+	require.Contains(t, request.Attributes(), attribute.Int("uncover.attempts", 1))
+}
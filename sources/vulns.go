@@ -0,0 +1,43 @@
+package sources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// cveQueries are the query templates of engines able to search by CVE identifier
+var cveQueries = map[string]string{
+	"shodan":    "vuln:%s",
+	"netlas":    "cve.name:%s",
+	"greynoise": "cve:%s",
+}
+
+// CVEQuery returns the query of the engine searching assets vulnerable to the cve,
+// it returns an empty string if the engine cannot search by CVE identifier
+func CVEQuery(engine, cve string) string {
+	template, ok := cveQueries[engine]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(template, strings.ToUpper(strings.TrimSpace(cve)))
+}
+
+// SupportsCVE returns true if the engine can search by CVE identifier
+func SupportsCVE(engine string) bool {
+	_, ok := cveQueries[engine]
+	return ok
+}
+
+// NormalizeVulns returns the sorted unique upper case vulnerability identifiers
+func NormalizeVulns(vulns []string) []string {
+	var normalized []string
+	for _, vuln := range vulns {
+		vuln = strings.ToUpper(strings.TrimSpace(vuln))
+		if vuln != "" && !slices.Contains(normalized, vuln) {
+			normalized = append(normalized, vuln)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
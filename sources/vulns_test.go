@@ -0,0 +1,22 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCVEQuery(t *testing.T) {
+	require.Equal(t, "vuln:CVE-2021-44228", CVEQuery("shodan", " cve-2021-44228 "))
+	require.Equal(t, "cve.name:CVE-2021-44228", CVEQuery("netlas", "CVE-2021-44228"))
+	require.Equal(t, "cve:CVE-2021-44228", CVEQuery("greynoise", "CVE-2021-44228"))
+	require.Empty(t, CVEQuery("fofa", "CVE-2021-44228"))
+	require.Empty(t, CVEQuery("criminalip", "CVE-2021-44228"))
+	require.True(t, SupportsCVE("netlas"))
+	require.False(t, SupportsCVE("criminalip"))
+}
+
+func TestNormalizeVulns(t *testing.T) {
+	require.Equal(t, []string{"CVE-2019-0708", "CVE-2021-44228"}, NormalizeVulns([]string{"cve-2021-44228", "CVE-2019-0708", " CVE-2021-44228", ""}))
+	require.Nil(t, NormalizeVulns(nil))
+}
@@ -2,6 +2,10 @@ package uncover
 
 import (
 	"context"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,6 +32,9 @@ import (
 
 	errorutil "github.com/projectdiscovery/utils/errors"
 	stringsutil "github.com/projectdiscovery/utils/strings"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var DefaultChannelBuffSize = 32
@@ -36,13 +43,40 @@ type Options struct {
 	Agents   []string // Uncover Agents to use
 	Queries  []string // Queries to pass to Agents
 	Limit    int
-	MaxRetry int
-	Timeout  int
-	// Note these ratelimits are used as fallback in case agent
-	// ratelimit is not available in DefaultRateLimits
-	RateLimit     uint          // default 30 req
-	RateLimitUnit time.Duration // default unit
-	Proxy         string        // http proxy to use with uncover
+	MaxRetry int // number of times a failed request is retried
+	Timeout  int // http timeout in seconds, sources.DefaultTimeout if zero
+	// RateLimit per RateLimitUnit overrides the rate limit of all engines,
+	// engines use their sources.DefaultRateLimits if zero
+	RateLimit     uint
+	RateLimitUnit time.Duration // default time.Second
+	// RateLimits overrides the rate limit of engines by name (e.g. shodan)
+	RateLimits map[string]sources.RateLimit
+	Proxy      string // http proxy to use with uncover
+	// HTTP is the http client configuration (proxy, tls, headers and user agent) of all engines
+	HTTP sources.HTTPOptions
+	// EngineHTTP overrides the http client configuration of engines by name
+	EngineHTTP map[string]sources.HTTPOptions
+	// EngineOptions contains the query options of engines by name
+	// (e.g. fofa: full=true, size=500) passed to each query of the engine
+	EngineOptions map[string]map[string]string
+	// DebugRequest and DebugResponse log the http exchanges with engines
+	DebugRequest  bool
+	DebugResponse bool
+	// Provider contains the keys used by the service, if nil keys are loaded from
+	// Keys, ProviderFile or the default provider config file and env variables
+	Provider *sources.Provider
+	// Keys contains in memory keys by engine name (e.g. shodan), the provider
+	// config file and env variables are ignored when set
+	Keys map[string][]string
+	// ProviderFile is the location of the provider config file to load keys from
+	ProviderFile string
+}
+
+// Executor executes uncover searches, implemented by the local Service
+// and by clients of remote uncover servers
+type Executor interface {
+	Execute(ctx context.Context) (<-chan sources.Result, error)
+	ExecuteWithCallback(ctx context.Context, callback func(result sources.Result)) error
 }
 
 // Service handler of all uncover Agents
@@ -52,69 +86,200 @@ type Service struct {
 	Session  *sources.Session
 	Provider *sources.Provider
 	Keys     sources.Keys
+	Stats    *sources.Stats
+	// Metrics contains the prometheus metrics of the service, its
+	// Registry can be served or gathered by library callers
+	Metrics *sources.Metrics
 }
 
 // New creates new uncover service instance
 func New(opts *Options) (*Service, error) {
 	s := &Service{Agents: []sources.Agent{}, Options: opts}
 	for _, v := range opts.Agents {
-		switch v {
-		case "shodan":
-			s.Agents = append(s.Agents, &shodan.Agent{})
-		case "censys":
-			s.Agents = append(s.Agents, &censys.Agent{})
-		case "fofa":
-			s.Agents = append(s.Agents, &fofa.Agent{})
-		case "shodan-idb":
-			s.Agents = append(s.Agents, &shodanidb.Agent{})
-		case "quake":
-			s.Agents = append(s.Agents, &quake.Agent{})
-		case "hunter":
-			s.Agents = append(s.Agents, &hunter.Agent{})
-		case "zoomeye":
-			s.Agents = append(s.Agents, &zoomeye.Agent{})
-		case "netlas":
-			s.Agents = append(s.Agents, &netlas.Agent{})
-		case "criminalip":
-			s.Agents = append(s.Agents, &criminalip.Agent{})
-		case "publicwww":
-			s.Agents = append(s.Agents, &publicwww.Agent{})
-		case "hunterhow":
-			s.Agents = append(s.Agents, &hunterhow.Agent{})
-		case "google":
-			s.Agents = append(s.Agents, &google.Agent{})
-		case "odin":
-			s.Agents = append(s.Agents, &odin.Agent{})
-		case "binaryedge":
-			s.Agents = append(s.Agents, &binaryedge.Agent{})
-		case "onyphe":
-			s.Agents = append(s.Agents, &onyphe.Agent{})
-		case "driftnet":
-			s.Agents = append(s.Agents, &driftnet.Agent{})
-		case "greynoise":
-			s.Agents = append(s.Agents, &greynoise.Agent{})
-		case "nerdydata":
-			s.Agents = append(s.Agents, &nerdydata.Agent{})
+		if agent := newAgent(v); agent != nil {
+			s.Agents = append(s.Agents, agent)
 		}
 	}
-	s.Provider = sources.NewProvider()
+	var err error
+	s.Provider, err = opts.provider()
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not load provider keys")
+	}
 	s.Keys = s.Provider.GetKeys()
 
-	if opts.RateLimit == 0 {
-		opts.RateLimit = 30
-	}
-	if opts.RateLimitUnit == 0 {
-		opts.RateLimitUnit = time.Minute
+	sessionOpts, err := opts.sessionOptions(&s.Keys)
+	if err != nil {
+		return nil, err
 	}
-
-	var err error
-	s.Session, err = sources.NewSession(&s.Keys, opts.MaxRetry, opts.Timeout, 10, opts.Agents, opts.RateLimitUnit, opts.Proxy)
+	s.Session, err = sources.NewSessionWithOptions(sessionOpts)
 	if err != nil {
 		return nil, err
 	}
+	s.Session.Redactor.Add(s.Provider.Secrets()...)
+	s.Session.DebugRequests = opts.DebugRequest
+	s.Session.DebugResponses = opts.DebugResponse
+	s.Stats = sources.NewStats()
+	s.Session.Stats = s.Stats
+	s.Metrics = sources.NewMetrics()
+	s.Session.Metrics = s.Metrics
 	return s, nil
 }
 
+// provider returns the provider configured by the options
+func (opts *Options) provider() (*sources.Provider, error) {
+	switch {
+	case opts.Provider != nil:
+		return opts.Provider, nil
+	case opts.Keys != nil:
+		return sources.LoadProvider(sources.WithProviderKeys(opts.Keys))
+	case opts.ProviderFile != "":
+		return sources.LoadProvider(sources.WithProviderConfig(opts.ProviderFile))
+	}
+	return sources.NewProvider(), nil
+}
+
+// sessionOptions returns the validated session options of the options
+func (opts *Options) sessionOptions(keys *sources.Keys) (*sources.SessionOptions, error) {
+	sessionOpts := &sources.SessionOptions{
+		Keys:       keys,
+		RetryMax:   opts.MaxRetry,
+		Timeout:    opts.Timeout,
+		Engines:    opts.Agents,
+		RateLimits: make(map[string]sources.RateLimit),
+		Proxy:      opts.Proxy,
+		HTTP:       opts.HTTP,
+		EngineHTTP: opts.EngineHTTP,
+	}
+	if opts.RateLimit > 0 {
+		unit := opts.RateLimitUnit
+		if unit == 0 {
+			unit = time.Second
+		}
+		sessionOpts.RateLimit = sources.RateLimit{MaxCount: opts.RateLimit, Duration: unit}
+		// the global rate limit overrides the default rate limits of engines
+		for _, engine := range AllAgents() {
+			sessionOpts.RateLimits[engine] = sessionOpts.RateLimit
+		}
+	}
+	for engine, rateLimit := range opts.RateLimits {
+		if !stringsutil.EqualFoldAny(engine, AllAgents()...) {
+			return nil, errorutil.NewWithTag("uncover", "rate limit configured for unknown engine %s", engine)
+		}
+		sessionOpts.RateLimits[strings.ToLower(engine)] = rateLimit
+	}
+	for engine := range opts.EngineHTTP {
+		if !stringsutil.EqualFoldAny(engine, AllAgents()...) {
+			return nil, errorutil.NewWithTag("uncover", "http options configured for unknown engine %s", engine)
+		}
+	}
+	for engine, engineOptions := range opts.EngineOptions {
+		if !stringsutil.EqualFoldAny(engine, AllAgents()...) {
+			return nil, errorutil.NewWithTag("uncover", "query options configured for unknown engine %s", engine)
+		}
+		var supported []string
+		if agent, ok := newAgent(strings.ToLower(engine)).(sources.OptionAgent); ok {
+			supported = agent.QueryOptions()
+		}
+		for option := range engineOptions {
+			if slices.Contains(supported, option) {
+				continue
+			}
+			if len(supported) == 0 {
+				return nil, errorutil.NewWithTag("uncover", "query option %s configured for %s which has no query options", option, engine)
+			}
+			return nil, errorutil.NewWithTag("uncover", "unknown query option %s of %s, supported options are %s", option, engine, strings.Join(supported, ", "))
+		}
+	}
+	return sessionOpts, sessionOpts.Validate()
+}
+
+// engineOptions returns the query options of the engine, engine names are case insensitive
+func (opts *Options) engineOptions(engine string) map[string]string {
+	for name, engineOptions := range opts.EngineOptions {
+		if strings.EqualFold(name, engine) {
+			return engineOptions
+		}
+	}
+	return nil
+}
+
+// Fork creates a new service for given agents, queries and limit which shares
+// the session, rate limits, provider keys, stats, metrics and agent instances of the parent service
+func (s *Service) Fork(opts *Options) *Service {
+	forkOpts := *s.Options
+	forkOpts.Agents = opts.Agents
+	forkOpts.Queries = opts.Queries
+	forkOpts.Limit = opts.Limit
+
+	fork := &Service{
+		Options:  &forkOpts,
+		Agents:   []sources.Agent{},
+		Session:  s.Session,
+		Provider: s.Provider,
+		Keys:     s.Keys,
+		Stats:    s.Stats,
+		Metrics:  s.Metrics,
+	}
+	for _, v := range opts.Agents {
+		if agent := s.agent(v); agent != nil {
+			fork.Agents = append(fork.Agents, agent)
+		}
+	}
+	return fork
+}
+
+// agent returns the agent with given name of the service or a new instance
+func (s *Service) agent(name string) sources.Agent {
+	for _, agent := range s.Agents {
+		if agent.Name() == name {
+			return agent
+		}
+	}
+	return newAgent(name)
+}
+
+func newAgent(name string) sources.Agent {
+	switch name {
+	case "shodan":
+		return &shodan.Agent{}
+	case "censys":
+		return &censys.Agent{}
+	case "fofa":
+		return &fofa.Agent{}
+	case "shodan-idb":
+		return &shodanidb.Agent{}
+	case "quake":
+		return &quake.Agent{}
+	case "hunter":
+		return &hunter.Agent{}
+	case "zoomeye":
+		return &zoomeye.Agent{}
+	case "netlas":
+		return &netlas.Agent{}
+	case "criminalip":
+		return &criminalip.Agent{}
+	case "publicwww":
+		return &publicwww.Agent{}
+	case "hunterhow":
+		return &hunterhow.Agent{}
+	case "google":
+		return &google.Agent{}
+	case "odin":
+		return &odin.Agent{}
+	case "binaryedge":
+		return &binaryedge.Agent{}
+	case "onyphe":
+		return &onyphe.Agent{}
+	case "driftnet":
+		return &driftnet.Agent{}
+	case "greynoise":
+		return &greynoise.Agent{}
+	case "nerdydata":
+		return &nerdydata.Agent{}
+	}
+	return nil
+}
+
 func (s *Service) Execute(ctx context.Context) (<-chan sources.Result, error) {
 	// unlikely but as a precaution to handle random panics check all types
 	if err := s.nilCheck(); err != nil {
@@ -127,6 +292,13 @@ func (s *Service) Execute(ctx context.Context) (<-chan sources.Result, error) {
 		return nil, errorutil.NewWithTag("uncover", "agents %v requires keys but no keys were found", s.Options.Agents)
 	}
 
+	tracer := sources.Tracer()
+	runCtx, runSpan := tracer.Start(ctx, "uncover.run", trace.WithAttributes(
+		attribute.StringSlice("uncover.engines", s.Options.Agents),
+		attribute.Int("uncover.queries", len(s.Options.Queries)),
+		attribute.Int("uncover.limit", s.Options.Limit),
+	))
+
 	megaChan := make(chan sources.Result, DefaultChannelBuffSize)
 	// iterate and run all sources
 	wg := &sync.WaitGroup{}
@@ -138,17 +310,42 @@ func (s *Service) Execute(ctx context.Context) (<-chan sources.Result, error) {
 				gologger.Error().Msgf(agent.Name(), "agent given but keys not found")
 				continue agentLabel
 			}
-			ch, err := agent.Query(s.Session, &sources.Query{
-				Query: q,
-				Limit: s.Options.Limit,
+			queryCtx, querySpan := tracer.Start(runCtx, "uncover.query", trace.WithAttributes(
+				attribute.String("uncover.engine", agent.Name()),
+				attribute.String("uncover.query", q),
+			))
+			ch, err := queryAgent(agent, s.Session.WithContext(queryCtx), &sources.Query{
+				Query:   q,
+				Limit:   s.Options.Limit,
+				Options: s.Options.engineOptions(agent.Name()),
 			})
 			if err != nil {
+				err = s.Session.Redactor.Error(err)
+				s.Stats.AddError(agent.Name(), err)
+				s.Metrics.AddError(agent.Name(), err)
+				querySpan.RecordError(err)
+				querySpan.SetStatus(codes.Error, err.Error())
+				querySpan.End()
 				gologger.Error().Msgf("%s\n", err)
 				continue agentLabel
 			}
 			wg.Add(1)
-			go func(source, relay chan sources.Result, ctx context.Context) {
+			s.Stats.QueryStarted(agent.Name(), q)
+			go func(source, relay chan sources.Result, ctx context.Context, span trace.Span, engine, query string) {
 				defer wg.Done()
+				defer s.Stats.QueryFinished(engine, query)
+				var results, failures int
+				defer func() {
+					span.SetAttributes(attribute.Int("uncover.results", results), attribute.Int("uncover.errors", failures))
+					span.End()
+				}()
+				// drain remaining results on cancellation so the agent goroutine can exit
+				defer func() {
+					go func() {
+						for range source {
+						}
+					}()
+				}()
 				for {
 					select {
 					case <-ctx.Done():
@@ -158,22 +355,47 @@ func (s *Service) Execute(ctx context.Context) (<-chan sources.Result, error) {
 						if !ok {
 							return
 						}
-						relay <- res
+						// agents may embed keys in their error messages
+						res.Error = s.Session.Redactor.Error(res.Error)
+						s.Stats.AddResult(engine, query, res)
+						s.Metrics.AddResult(engine, res)
+						if res.Error != nil {
+							failures++
+							span.RecordError(res.Error)
+						} else {
+							results++
+						}
+						select {
+						case <-ctx.Done():
+							return
+						case relay <- res:
+						}
 					}
 				}
-			}(ch, megaChan, ctx)
+			}(ch, megaChan, ctx, querySpan, agent.Name(), q)
 		}
 	}
 
 	// close channel when all sources return
 	go func(wg *sync.WaitGroup, megaChan chan sources.Result) {
 		wg.Wait()
+		runSpan.End()
 		defer close(megaChan)
 	}(wg, megaChan)
 
 	return megaChan, nil
 }
 
+// queryAgent starts the query of the agent, a panic of the agent is returned as error
+func queryAgent(agent sources.Agent, session *sources.Session, query *sources.Query) (ch chan sources.Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s agent crashed: %v\n%s", agent.Name(), r, debug.Stack())
+		}
+	}()
+	return agent.Query(session, query)
+}
+
 // ExecuteWithWriters writes output to writer along with stdout
 func (s *Service) ExecuteWithCallback(ctx context.Context, callback func(result sources.Result)) error {
 	ch, err := s.Execute(ctx)
@@ -198,6 +420,11 @@ func (s *Service) ExecuteWithCallback(ctx context.Context, callback func(result
 
 // AllAgents returns all supported uncover Agents
 func (s *Service) AllAgents() []string {
+	return AllAgents()
+}
+
+// AllAgents returns all supported uncover Agents
+func AllAgents() []string {
 	return []string{
 		"shodan", "censys", "fofa", "shodan-idb", "quake", "hunter", "zoomeye", "netlas", "criminalip", "publicwww", "hunterhow", "google", "odin", "binaryedge", "onyphe", "driftnet", "greynoise", "nerdydata",
 	}
@@ -0,0 +1,158 @@
+package uncover
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/uncover/sources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIsolatedServices(t *testing.T) {
+	location := sources.DefaultProviderConfigLocation
+	sources.DefaultProviderConfigLocation = filepath.Join(t.TempDir(), "provider-config.yaml")
+	defer func() { sources.DefaultProviderConfigLocation = location }()
+	t.Setenv("SHODAN_API_KEY", "env-key")
+
+	var firstProxied, secondProxied atomic.Int32
+	firstProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { firstProxied.Add(1) }))
+	defer firstProxy.Close()
+	secondProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { secondProxied.Add(1) }))
+	defer secondProxy.Close()
+
+	first, err := New(&Options{
+		Agents: []string{"shodan"},
+		Keys:   map[string][]string{"shodan": {"first-key"}},
+		Proxy:  firstProxy.URL,
+	})
+	require.Nil(t, err)
+	second, err := New(&Options{
+		Agents:   []string{"shodan"},
+		Provider: &sources.Provider{Shodan: []string{"second-key"}},
+		Proxy:    secondProxy.URL,
+	})
+	require.Nil(t, err)
+
+	require.Equal(t, "first-key", first.Keys.Shodan)
+	require.Equal(t, "second-key", second.Keys.Shodan)
+	require.Equal(t, []string{"first-key"}, first.Provider.Shodan)
+	require.NotSame(t, first.Session, second.Session)
+
+	for _, service := range []*Service{first, second, second} {
+		req, err := sources.NewHTTPRequest(http.MethodGet, "http://api.shodan.invalid/", nil)
+		require.Nil(t, err)
+		_, err = service.Session.Do(req, "shodan")
+		require.Nil(t, err)
+	}
+	require.Equal(t, int32(1), firstProxied.Load())
+	require.Equal(t, int32(2), secondProxied.Load())
+	require.NoFileExists(t, sources.DefaultProviderConfigLocation)
+
+	_, err = New(&Options{Agents: []string{"shodan"}, ProviderFile: filepath.Join(t.TempDir(), "missing.yaml")})
+	require.ErrorContains(t, err, "does not exist")
+}
+
+func TestNewSessionOptions(t *testing.T) {
+	service, err := New(&Options{
+		Agents:     []string{"shodan", "fofa", "censys"},
+		Keys:       map[string][]string{"shodan": {"key"}},
+		MaxRetry:   3,
+		Timeout:    5,
+		RateLimit:  4,
+		RateLimits: map[string]sources.RateLimit{"Shodan": {MaxCount: 2, Duration: time.Second}},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 3, service.Session.RetryMax)
+	require.Equal(t, 5*time.Second, service.Session.Client.HTTPClient.Timeout)
+	// the global rate limit overrides default rate limits but not per engine rate limits
+	for engine, expected := range map[string]uint{"shodan": 2, "fofa": 4, "censys": 4} {
+		limit, err := service.Session.RateLimits.GetLimit(engine)
+		require.Nil(t, err)
+		require.Equal(t, expected, limit, engine)
+	}
+
+	// the global rate limit is per second if no unit is set
+	sessionOpts, err := (&Options{Agents: []string{"fofa"}, RateLimit: 4}).sessionOptions(&sources.Keys{})
+	require.Nil(t, err)
+	require.Equal(t, sources.RateLimit{MaxCount: 4, Duration: time.Second}, sessionOpts.RateLimits["fofa"])
+
+	// engines keep their default rate limits and a zero timeout uses the default
+	service, err = New(&Options{Agents: []string{"fofa"}, Keys: map[string][]string{"fofa": {"email:key"}}})
+	require.Nil(t, err)
+	require.Equal(t, sources.DefaultTimeout*time.Second, service.Session.Client.HTTPClient.Timeout)
+	limit, err := service.Session.RateLimits.GetLimit("fofa")
+	require.Nil(t, err)
+	require.Equal(t, sources.DefaultRateLimits["fofa"].MaxCount, limit)
+
+	_, err = New(&Options{Agents: []string{"shodan"}, Keys: map[string][]string{}, RateLimits: map[string]sources.RateLimit{"unknown": {MaxCount: 1, Duration: time.Second}}})
+	require.ErrorContains(t, err, "unknown engine")
+	_, err = New(&Options{Agents: []string{"fofa"}, Keys: map[string][]string{}, EngineOptions: map[string]map[string]string{"unknown": {"full": "true"}}})
+	require.ErrorContains(t, err, "unknown engine")
+	_, err = New(&Options{Agents: []string{"fofa"}, Keys: map[string][]string{}, EngineOptions: map[string]map[string]string{"fofa": {"ful": "true"}}})
+	require.ErrorContains(t, err, "unknown query option ful of fofa")
+	_, err = New(&Options{Agents: []string{"shodan"}, Keys: map[string][]string{}, EngineOptions: map[string]map[string]string{"shodan": {"full": "true"}}})
+	require.ErrorContains(t, err, "has no query options")
+	service, err = New(&Options{Agents: []string{"fofa"}, Keys: map[string][]string{}, EngineOptions: map[string]map[string]string{"FOFA": {"full": "true"}}})
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"full": "true"}, service.Options.engineOptions("fofa"))
+	_, err = New(&Options{Agents: []string{"shodan"}, Keys: map[string][]string{}, Timeout: -1})
+	require.ErrorContains(t, err, "timeout cannot be negative")
+}
+
+func TestServiceFacets(t *testing.T) {
+	service, err := New(&Options{
+		Agents:  []string{"shodan", "google"},
+		Queries: []string{"ssl:example.com"},
+		Keys:    map[string][]string{},
+	})
+	require.Nil(t, err)
+
+	_, err = service.Facets(context.Background(), nil)
+	require.ErrorContains(t, err, "no facet field specified")
+
+	engineFacets, err := service.Facets(context.Background(), []string{"port"})
+	require.Nil(t, err)
+	require.Len(t, engineFacets, 2)
+	require.Equal(t, "shodan", engineFacets[0].Engine)
+	require.Equal(t, "empty shodan keys", engineFacets[0].Error)
+	require.Equal(t, "google", engineFacets[1].Engine)
+	require.Equal(t, "facets are not supported", engineFacets[1].Error)
+}
+
+func TestServiceCount(t *testing.T) {
+	service, err := New(&Options{
+		Agents:  []string{"hunter", "google"},
+		Queries: []string{"web.title=\"jira\""},
+		Keys:    map[string][]string{},
+	})
+	require.Nil(t, err)
+
+	counts, err := service.Count(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, []EngineCount{
+		{Engine: "hunter", Query: "web.title=\"jira\"", Error: "empty hunter keys"},
+		{Engine: "google", Query: "web.title=\"jira\"", Error: "counting results is not supported"},
+	}, counts)
+}
+
+// panicAgent panics while starting its query
+type panicAgent struct{}
+
+func (agent *panicAgent) Name() string {
+	return "shodan"
+}
+
+func (agent *panicAgent) Query(_ *sources.Session, _ *sources.Query) (chan sources.Result, error) {
+	panic("crash")
+}
+
+func TestQueryAgentRecover(t *testing.T) {
+	ch, err := queryAgent(&panicAgent{}, &sources.Session{}, &sources.Query{Query: "test"})
+	require.Nil(t, ch)
+	require.ErrorContains(t, err, "shodan agent crashed: crash")
+}